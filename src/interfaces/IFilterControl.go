@@ -0,0 +1,27 @@
+//
+//  IFilterControl.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package interfaces
+
+/*
+IFilterControl Filter Control Message Interface.
+
+Implemented by messages that control the behavior of
+filters, such as the messages.FilterControlMessage. Filters
+only act on a filter control message type if the message
+implements this interface, and reject it otherwise, so any
+IPipeMessage can be written down a pipeline safely.
+*/
+type IFilterControl interface {
+	IPipeMessage
+	Name() string                                 // Get the name of the target filter
+	Predicate() string                            // Get the name of the target predicate within a filter chain, if any
+	Filter() func(IPipeMessage, interface{}) bool // Get the filter function
+	Params() interface{}                          // Get the filter parameters
+	Expression() string                           // Get the filter expression
+}
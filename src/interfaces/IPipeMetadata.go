@@ -0,0 +1,23 @@
+//
+//  IPipeMetadata.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package interfaces
+
+/*
+IPipeMetadata Pipe Metadata Interface.
+
+Can be implemented by any IPipeMessage that carries
+metadata alongside its header and body, such as a
+message ID. Fittings use metadata to annotate messages
+they pass along without touching the header or body,
+which belong to the application.
+*/
+type IPipeMetadata interface {
+	Metadata(key string) interface{}           // Get a metadata value of this message, nil if not set
+	SetMetadata(key string, value interface{}) // Set a metadata value of this message
+}
@@ -0,0 +1,62 @@
+//
+//  CircuitBreakerControlMessage.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package messages
+
+const (
+	BREAKER_OPEN  = "http://puremvc.org/namespaces/pipes/messages/normal/breaker-control/open"  // Force the circuit breaker open.
+	BREAKER_CLOSE = "http://puremvc.org/namespaces/pipes/messages/normal/breaker-control/close" // Force the circuit breaker closed.
+	BREAKER_RESET = "http://puremvc.org/namespaces/pipes/messages/normal/breaker-control/reset" // Return the circuit breaker to automatic operation.
+)
+
+/*
+CircuitBreakerControlMessage Circuit Breaker Control Message.
+
+A special message type for controlling the behavior of a CircuitBreaker.
+
+The messages.BREAKER_OPEN message type tells the CircuitBreaker
+to open and stay open, short-circuiting all normal messages
+until it is closed or reset.
+
+The messages.BREAKER_CLOSE message type tells the CircuitBreaker
+to close and stay closed, regardless of failures, until it is
+opened or reset.
+
+The messages.BREAKER_RESET message type tells the CircuitBreaker
+to discard its failure statistics and return to automatic
+operation in the closed state.
+
+The CircuitBreaker only acts on a control message if it is targeted
+to this named circuit breaker instance. Otherwise it writes the
+message through to its output unchanged.
+*/
+type CircuitBreakerControlMessage struct {
+	Message
+	name string
+}
+
+/*
+NewCircuitBreakerControlMessage Constructor
+*/
+func NewCircuitBreakerControlMessage(_type string, name string) *CircuitBreakerControlMessage {
+	return &CircuitBreakerControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, name: name}
+}
+
+/*
+SetName Set the target circuit breaker name.
+*/
+func (self *CircuitBreakerControlMessage) SetName(name string) {
+	self.name = name
+}
+
+/*
+Name Get the target circuit breaker name.
+*/
+func (self *CircuitBreakerControlMessage) Name() string {
+	return self.name
+}
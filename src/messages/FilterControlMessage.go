@@ -15,6 +15,8 @@ const (
 	SET_FILTER = "http://puremvc.org/namespaces/pipes/messages/normal/filter-control/setFilter" // Set filter function.
 	BYPASS     = "http://puremvc.org/namespaces/pipes/messages/normal/filter-control/bypass"    // Toggle to filter bypass mode.
 	FILTER     = "http://puremvc.org/namespaces/pipes/messages/normal/filter-control/filter"    // Toggle to filtering mode. (default behavior).
+
+	SET_EXPRESSION = "http://puremvc.org/namespaces/pipes/messages/normal/filter-control/setExpression" // Set filter expression.
 )
 
 /*
@@ -28,6 +30,10 @@ to retrieve the filter parameters object.
 The messages.SET_FILTER message type tells the Filter
 to retrieve the filter function.
 
+The messages.SET_EXPRESSION message type tells the Filter
+to compile the filter expression and use it as the filter
+function. See plumbing.Expression for the expression language.
+
 The messages.BYPASS message type tells the Filter
 that it should go into Bypass mode operation, passing all normal
 messages through unfiltered.
@@ -41,12 +47,18 @@ cancel a previous FilterControlMessage.BYPASS message.
 The Filter only acts on a control message if it is targeted
 to this named filter instance. Otherwise it writes the
 message through to its output unchanged.
+
+A FilterChain also accepts these messages. If they name a
+predicate of the chain they act on that predicate alone,
+otherwise on the whole chain.
 */
 type FilterControlMessage struct {
 	Message
-	name   string
-	filter func(interfaces.IPipeMessage, interface{}) bool
-	params interface{}
+	name       string
+	predicate  string
+	filter     func(interfaces.IPipeMessage, interface{}) bool
+	params     interface{}
+	expression string
 }
 
 /*
@@ -56,6 +68,22 @@ func NewFilterControlMessage(_type string, name string, filter func(interfaces.I
 	return &FilterControlMessage{Message: Message{_type: _type}, name: name, filter: filter, params: params}
 }
 
+/*
+NewFilterChainControlMessage Constructor for a message targeting a named predicate of a FilterChain.
+*/
+func NewFilterChainControlMessage(_type string, name string, predicate string, filter func(interfaces.IPipeMessage, interface{}) bool, params interface{}) *FilterControlMessage {
+	return &FilterControlMessage{Message: Message{_type: _type}, name: name, predicate: predicate, filter: filter, params: params}
+}
+
+/*
+NewFilterExpressionControlMessage Constructor for a messages.SET_EXPRESSION message.
+
+The predicate name is only used by a FilterChain, and may be empty.
+*/
+func NewFilterExpressionControlMessage(name string, predicate string, expression string) *FilterControlMessage {
+	return &FilterControlMessage{Message: Message{_type: SET_EXPRESSION}, name: name, predicate: predicate, expression: expression}
+}
+
 /*
 SetName Set the target filter name.
 */
@@ -70,6 +98,20 @@ func (self *FilterControlMessage) Name() string {
 	return self.name
 }
 
+/*
+SetPredicate  Set the target predicate name within a FilterChain.
+*/
+func (self *FilterControlMessage) SetPredicate(predicate string) {
+	self.predicate = predicate
+}
+
+/*
+Predicate  Get the target predicate name within a FilterChain.
+*/
+func (self *FilterControlMessage) Predicate() string {
+	return self.predicate
+}
+
 /*
 SetFilter  Set the filter function.
 */
@@ -97,3 +139,17 @@ Params  Get the parameters object.
 func (self *FilterControlMessage) Params() interface{} {
 	return self.params
 }
+
+/*
+SetExpression  Set the filter expression.
+*/
+func (self *FilterControlMessage) SetExpression(expression string) {
+	self.expression = expression
+}
+
+/*
+Expression  Get the filter expression.
+*/
+func (self *FilterControlMessage) Expression() string {
+	return self.expression
+}
@@ -8,7 +8,10 @@
 
 package messages
 
-import "github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"sync"
+)
 
 const (
 	PRIORITY_HIGH = 1                                                      // High priority Messages can be sorted to the front of the queue
@@ -17,6 +20,19 @@ const (
 	NORMAL        = "http://puremvc.org/namespaces/pipes/messages/normal/" // Normal Message type
 )
 
+const (
+	METADATA_ID                = "id"              // Metadata key of the message ID
+	METADATA_SOURCE            = "source"          // Metadata key of the label of the input a message was merged from
+	METADATA_TIMESTAMP         = "timestamp"       // Metadata key of the time.Time the event a message describes happened
+	METADATA_DELIVER_AT        = "deliverAt"       // Metadata key of the time.Time a Scheduler should deliver a message at
+	METADATA_DELAY             = "delay"           // Metadata key of the time.Duration a Scheduler should hold a message for
+	METADATA_DEADLINE          = "deadline"        // Metadata key of the time.Time a message should be handled by
+	METADATA_SENDER            = "sender"          // Metadata key of the identity a module claims to send a message as
+	METADATA_SIGNATURE         = "signature"       // Metadata key of the base64 HMAC of a message, set by a Signer
+	METADATA_SIGNATURE_KEY_ID  = "signatureKeyID"  // Metadata key of the ID of the key a message was signed with
+	METADATA_ENCRYPTION_KEY_ID = "encryptionKeyID" // Metadata key of the ID of the key the body of a message was encrypted with
+)
+
 /*
 Message Pipe Message.
 
@@ -26,12 +42,18 @@ be sorted by priority. Based on type,
 they may used as control messages to modify the
 behavior of filter or queue fittings connected
 to the pipeline into which they are written.
+
+Messages also carry metadata, which fittings may use to
+annotate them without touching the header or body. The
+metadata is safe to read and write from several goroutines.
 */
 type Message struct {
 	_type    string
 	header   interface{}
 	body     interface{}
 	priority int
+	metadata map[string]interface{}
+	mutex    sync.RWMutex
 }
 
 /*
@@ -96,3 +118,95 @@ SetBody Set the body of this message
 func (self *Message) SetBody(body interface{}) {
 	self.body = body
 }
+
+/*
+Metadata Get a metadata value of this message, nil if not set
+*/
+func (self *Message) Metadata(key string) interface{} {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	return self.metadata[key]
+}
+
+/*
+SetMetadata Set a metadata value of this message
+*/
+func (self *Message) SetMetadata(key string, value interface{}) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.metadata == nil {
+		self.metadata = make(map[string]interface{})
+	}
+	self.metadata[key] = value
+}
+
+/*
+Metadata Get a metadata value of any message.
+
+- parameter message: the message to read from
+
+- parameter key: the metadata key
+
+- returns: interface{} the value, or nil if not set or the message does not implement IPipeMetadata
+*/
+func Metadata(message interfaces.IPipeMessage, key string) interface{} {
+	if meta, ok := message.(interfaces.IPipeMetadata); ok {
+		return meta.Metadata(key)
+	}
+	return nil
+}
+
+/*
+SetMetadata Set a metadata value of any message.
+
+- parameter message: the message to annotate
+
+- parameter key: the metadata key
+
+- parameter value: the metadata value
+
+- returns: Bool false if the message does not implement IPipeMetadata
+*/
+func SetMetadata(message interfaces.IPipeMessage, key string, value interface{}) bool {
+	meta, ok := message.(interfaces.IPipeMetadata)
+	if ok {
+		meta.SetMetadata(key, value)
+	}
+	return ok
+}
+
+/*
+Copy Get a copy of a message with its own metadata.
+
+Fittings that annotate or change a message copy it first, so
+that they do not touch the instance other branches of a
+pipeline share. The header and body themselves are shared by
+the copy.
+
+Only messages of the concrete type *Message are copied.
+Control messages and other implementations are returned as
+they are, since fittings rely on their concrete type.
+
+- parameter message: the message to copy
+
+- returns: interfaces.IPipeMessage the copy, or the message itself if it is not a *Message
+*/
+func Copy(message interfaces.IPipeMessage) interfaces.IPipeMessage {
+	original, ok := message.(*Message)
+	if !ok {
+		return message
+	}
+	original.mutex.RLock()
+	defer original.mutex.RUnlock()
+
+	copied := &Message{_type: original._type, header: original.header, body: original.body, priority: original.priority}
+	if original.metadata != nil {
+		copied.metadata = make(map[string]interface{}, len(original.metadata))
+		for key, value := range original.metadata {
+			copied.metadata[key] = value
+		}
+	}
+	return copied
+}
@@ -8,10 +8,21 @@
 
 package messages
 
+import "github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+
 const (
 	FLUSH string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/flush" // Flush the queue.
-	SORT  string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/sort"  // Toggle to sort-by-priority operation mode.
+	SORT  string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/sort"  // Toggle to sort operation mode, by priority unless a comparator is set.
 	FIFO  string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/fifo"  // Toggle to FIFO operation mode (default behavior)
+
+	FLUSH_N     string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/flushN"     // Flush the first Count messages of the queue.
+	FLUSH_WHERE string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/flushWhere" // Flush the messages of the queue that match.
+	PURGE       string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/purge"      // Discard the messages of the queue that match.
+
+	PAUSE  string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/pause"  // Store normal messages (default behavior).
+	RESUME string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/resume" // Flush the queue, then pass normal messages through.
+
+	SET_COMPARATOR string = "http://puremvc.org/namespaces/pipes/messages/normal/queue/setComparator" // Sort the queue with the Comparator.
 )
 
 /*
@@ -22,13 +33,30 @@ A special message for controlling the behavior of a Queue.
 When written to a pipeline containing a Queue, the type
 of the message is interpreted and acted upon by the Queue.
 
-Unlike filters, multiple serially connected queues aren't
-very useful and so they do not require a name. If multiple
-queues are connected serially, the message will be acted
-upon by the first queue only.
+A message with an empty name is acted upon by the first
+unnamed queue on the pipeline, so a single queue does not
+require a name. When several queues are connected serially,
+give each a name and address the message to the queue that
+should act on it; the other queues write it through.
+
+The FLUSH_N message releases only the first Count messages of
+the queue. The FLUSH_WHERE and PURGE messages release or
+discard only the messages that match, selected by the Where
+function, or by the Expression if there is no Where function,
+so that a remote core can select messages without shipping
+a Go func. A PURGE message that selects nothing discards all
+messages.
+
+The SET_COMPARATOR message puts the queue into sort mode,
+ordering messages with the Comparator instead of by priority.
 */
 type QueueControlMessage struct {
 	Message
+	name       string
+	count      int
+	where      func(interfaces.IPipeMessage) bool
+	expression string
+	comparator func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int
 }
 
 /*
@@ -37,3 +65,105 @@ NewQueueControlMessage Constructor
 func NewQueueControlMessage(_type string) *QueueControlMessage {
 	return &QueueControlMessage{Message: Message{_type: _type, header: nil, body: nil, priority: PRIORITY_MED}}
 }
+
+/*
+NewNamedQueueControlMessage Constructor for a message addressed to a named queue.
+*/
+func NewNamedQueueControlMessage(_type string, name string) *QueueControlMessage {
+	return &QueueControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, name: name}
+}
+
+/*
+NewQueueCountControlMessage Constructor for a messages.FLUSH_N message.
+*/
+func NewQueueCountControlMessage(_type string, count int) *QueueControlMessage {
+	return &QueueControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, count: count}
+}
+
+/*
+NewQueueSelectControlMessage Constructor for a messages.FLUSH_WHERE or messages.PURGE message.
+
+Give either a where function, or an expression for a remote core.
+*/
+func NewQueueSelectControlMessage(_type string, where func(interfaces.IPipeMessage) bool, expression string) *QueueControlMessage {
+	return &QueueControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, where: where, expression: expression}
+}
+
+/*
+NewQueueComparatorControlMessage Constructor for a messages.SET_COMPARATOR message.
+
+Give a nil comparator to sort by priority again.
+*/
+func NewQueueComparatorControlMessage(_type string, comparator func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int) *QueueControlMessage {
+	return &QueueControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, comparator: comparator}
+}
+
+/*
+SetName Set the target queue name.
+*/
+func (self *QueueControlMessage) SetName(name string) {
+	self.name = name
+}
+
+/*
+Name Get the target queue name.
+*/
+func (self *QueueControlMessage) Name() string {
+	return self.name
+}
+
+/*
+SetCount Set the number of messages to flush.
+*/
+func (self *QueueControlMessage) SetCount(count int) {
+	self.count = count
+}
+
+/*
+Count Get the number of messages to flush.
+*/
+func (self *QueueControlMessage) Count() int {
+	return self.count
+}
+
+/*
+SetWhere Set the function selecting messages.
+*/
+func (self *QueueControlMessage) SetWhere(where func(interfaces.IPipeMessage) bool) {
+	self.where = where
+}
+
+/*
+Where Get the function selecting messages.
+*/
+func (self *QueueControlMessage) Where() func(interfaces.IPipeMessage) bool {
+	return self.where
+}
+
+/*
+SetExpression Set the expression selecting messages.
+*/
+func (self *QueueControlMessage) SetExpression(expression string) {
+	self.expression = expression
+}
+
+/*
+Expression Get the expression selecting messages.
+*/
+func (self *QueueControlMessage) Expression() string {
+	return self.expression
+}
+
+/*
+SetComparator Set the function ordering messages.
+*/
+func (self *QueueControlMessage) SetComparator(comparator func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int) {
+	self.comparator = comparator
+}
+
+/*
+Comparator Get the function ordering messages.
+*/
+func (self *QueueControlMessage) Comparator() func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int {
+	return self.comparator
+}
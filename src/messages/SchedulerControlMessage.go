@@ -0,0 +1,67 @@
+//
+//  SchedulerControlMessage.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package messages
+
+const (
+	CANCEL = "http://puremvc.org/namespaces/pipes/messages/normal/scheduler-control/cancel" // Cancel the delivery of scheduled messages.
+)
+
+/*
+SchedulerControlMessage Scheduler Control Message.
+
+A special message type for controlling the behavior of a Scheduler.
+
+The messages.CANCEL message type tells the Scheduler to
+discard the scheduled messages with the given message ID,
+so they are never delivered.
+
+The Scheduler only acts on a control message if it is targeted
+to this named scheduler instance. Otherwise it writes the
+message through to its output unchanged.
+*/
+type SchedulerControlMessage struct {
+	Message
+	name string
+	id   string
+}
+
+/*
+NewSchedulerControlMessage Constructor
+*/
+func NewSchedulerControlMessage(_type string, name string, id string) *SchedulerControlMessage {
+	return &SchedulerControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, name: name, id: id}
+}
+
+/*
+SetName Set the target scheduler name.
+*/
+func (self *SchedulerControlMessage) SetName(name string) {
+	self.name = name
+}
+
+/*
+Name Get the target scheduler name.
+*/
+func (self *SchedulerControlMessage) Name() string {
+	return self.name
+}
+
+/*
+SetID Set the message ID of the scheduled messages.
+*/
+func (self *SchedulerControlMessage) SetID(id string) {
+	self.id = id
+}
+
+/*
+ID Get the message ID of the scheduled messages.
+*/
+func (self *SchedulerControlMessage) ID() string {
+	return self.id
+}
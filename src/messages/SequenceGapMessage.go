@@ -0,0 +1,58 @@
+//
+//  SequenceGapMessage.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package messages
+
+const (
+	GAP = "http://puremvc.org/namespaces/pipes/messages/normal/resequencer/gap" // Sequence numbers were skipped.
+)
+
+/*
+SequenceGapMessage Sequence Gap Message.
+
+A special message written by a Resequencer when it gives up
+waiting for missing sequence numbers and skips past them.
+
+It tells the fittings and listeners downstream which stream
+the gap occurred in and the first and last missing sequence
+numbers.
+*/
+type SequenceGapMessage struct {
+	Message
+	stream string
+	from   int
+	to     int
+}
+
+/*
+NewSequenceGapMessage Constructor
+*/
+func NewSequenceGapMessage(stream string, from int, to int) *SequenceGapMessage {
+	return &SequenceGapMessage{Message: Message{_type: GAP, priority: PRIORITY_MED}, stream: stream, from: from, to: to}
+}
+
+/*
+Stream Get the ID of the stream with the gap.
+*/
+func (self *SequenceGapMessage) Stream() string {
+	return self.stream
+}
+
+/*
+From Get the first missing sequence number.
+*/
+func (self *SequenceGapMessage) From() int {
+	return self.from
+}
+
+/*
+To Get the last missing sequence number.
+*/
+func (self *SequenceGapMessage) To() int {
+	return self.to
+}
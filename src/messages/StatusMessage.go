@@ -0,0 +1,99 @@
+//
+//  StatusMessage.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package messages
+
+import "sync"
+
+const (
+	STATUS = "http://puremvc.org/namespaces/pipes/messages/normal/status/query" // Query the status of fittings.
+)
+
+/*
+StatusRecord The status of one fitting, as reported to a StatusMessage.
+*/
+type StatusRecord struct {
+	Name    string                 // Name of the fitting, empty for unnamed fittings
+	Fitting string                 // Kind of fitting, e.g. "Filter" or "Queue"
+	State   map[string]interface{} // Mode, parameters and counters of the fitting
+}
+
+/*
+StatusMessage Status Query Message.
+
+A special message for asking the fittings of a running
+pipeline about their state.
+
+The query travels down the pipeline like any other control
+message. Each fitting it is addressed to appends a
+StatusRecord of its state, then writes the query through, so
+the terminal listener of the pipeline receives the collected
+report, with records in pipeline order.
+
+A query is addressed to the fittings of the given name. A
+query with an empty name is addressed to every fitting that
+can report its status, including unnamed ones like the Queue.
+*/
+type StatusMessage struct {
+	Message
+	name    string
+	records []StatusRecord
+	mutex   sync.Mutex
+}
+
+/*
+NewStatusMessage Constructor
+*/
+func NewStatusMessage(name string) *StatusMessage {
+	return &StatusMessage{Message: Message{_type: STATUS, priority: PRIORITY_MED}, name: name}
+}
+
+/*
+SetName Set the name of the fittings queried.
+*/
+func (self *StatusMessage) SetName(name string) {
+	self.name = name
+}
+
+/*
+Name Get the name of the fittings queried.
+*/
+func (self *StatusMessage) Name() string {
+	return self.name
+}
+
+/*
+IsAddressedTo Is the query addressed to a fitting of this name?
+*/
+func (self *StatusMessage) IsAddressedTo(name string) bool {
+	return self.name == "" || self.name == name
+}
+
+/*
+AddRecord Append the status of a fitting.
+
+Safe to call from fittings on parallel branches of a pipeline.
+*/
+func (self *StatusMessage) AddRecord(record StatusRecord) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.records = append(self.records, record)
+}
+
+/*
+Records Get the status records collected so far.
+*/
+func (self *StatusMessage) Records() []StatusRecord {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	records := make([]StatusRecord, len(self.records))
+	copy(records, self.records)
+	return records
+}
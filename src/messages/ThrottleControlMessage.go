@@ -0,0 +1,82 @@
+//
+//  ThrottleControlMessage.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package messages
+
+const (
+	SET_RATE = "http://puremvc.org/namespaces/pipes/messages/normal/throttle-control/setRate" // Set throttle rate and burst.
+)
+
+/*
+ThrottleControlMessage Throttle Control Message.
+
+A special message type for retuning a Throttle at runtime.
+
+The messages.SET_RATE message type tells the Throttle
+to retrieve the rate and burst and apply them to every
+key from then on.
+
+The Throttle only acts on a control message if it is targeted
+to this named throttle instance. Otherwise it writes the
+message through to its output unchanged.
+*/
+type ThrottleControlMessage struct {
+	Message
+	name  string
+	rate  float64
+	burst int
+}
+
+/*
+NewThrottleControlMessage Constructor
+*/
+func NewThrottleControlMessage(_type string, name string, rate float64, burst int) *ThrottleControlMessage {
+	return &ThrottleControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, name: name, rate: rate, burst: burst}
+}
+
+/*
+SetName Set the target throttle name.
+*/
+func (self *ThrottleControlMessage) SetName(name string) {
+	self.name = name
+}
+
+/*
+Name Get the target throttle name.
+*/
+func (self *ThrottleControlMessage) Name() string {
+	return self.name
+}
+
+/*
+SetRate Set the number of messages per second.
+*/
+func (self *ThrottleControlMessage) SetRate(rate float64) {
+	self.rate = rate
+}
+
+/*
+Rate Get the number of messages per second.
+*/
+func (self *ThrottleControlMessage) Rate() float64 {
+	return self.rate
+}
+
+/*
+SetBurst Set the number of messages that may pass at once.
+*/
+func (self *ThrottleControlMessage) SetBurst(burst int) {
+	self.burst = burst
+}
+
+/*
+Burst Get the number of messages that may pass at once.
+*/
+func (self *ThrottleControlMessage) Burst() int {
+	return self.burst
+}
@@ -0,0 +1,113 @@
+//
+//  TransformerControlMessage.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package messages
+
+import "github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+
+const (
+	SET_TRANSFORM_PARAMS = "http://puremvc.org/namespaces/pipes/messages/normal/transformer-control/setParams"    // Set transformer parameters.
+	SET_TRANSFORM        = "http://puremvc.org/namespaces/pipes/messages/normal/transformer-control/setTransform" // Set transform functions.
+	BYPASS_TRANSFORM     = "http://puremvc.org/namespaces/pipes/messages/normal/transformer-control/bypass"       // Toggle to transformer bypass mode.
+	TRANSFORM            = "http://puremvc.org/namespaces/pipes/messages/normal/transformer-control/transform"    // Toggle to transforming mode. (default behavior).
+)
+
+/*
+TransformerControlMessage Transformer Control Message.
+
+A special message type for controlling the behavior of a Transformer.
+
+The messages.SET_TRANSFORM_PARAMS message type tells the Transformer
+to retrieve the transform parameters object.
+
+The messages.SET_TRANSFORM message type tells the Transformer
+to retrieve the transform and expand functions.
+
+The messages.BYPASS_TRANSFORM message type tells the Transformer
+that it should go into Bypass mode operation, passing all normal
+messages through unchanged.
+
+The messages.TRANSFORM message type tells the Transformer
+that it should go into Transforming mode operation. This is the
+default mode of operation and so this message type need only be
+sent to cancel a previous BYPASS_TRANSFORM message.
+
+The Transformer only acts on a control message if it is targeted
+to this named transformer instance. Otherwise it writes the
+message through to its output unchanged.
+*/
+type TransformerControlMessage struct {
+	Message
+	name      string
+	transform func(interfaces.IPipeMessage, interface{}) (interfaces.IPipeMessage, error)
+	expand    func(interfaces.IPipeMessage, interface{}) ([]interfaces.IPipeMessage, error)
+	params    interface{}
+}
+
+/*
+NewTransformerControlMessage Constructor
+*/
+func NewTransformerControlMessage(_type string, name string, transform func(interfaces.IPipeMessage, interface{}) (interfaces.IPipeMessage, error), params interface{}) *TransformerControlMessage {
+	return &TransformerControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, name: name, transform: transform, params: params}
+}
+
+/*
+SetName Set the target transformer name.
+*/
+func (self *TransformerControlMessage) SetName(name string) {
+	self.name = name
+}
+
+/*
+Name Get the target transformer name.
+*/
+func (self *TransformerControlMessage) Name() string {
+	return self.name
+}
+
+/*
+SetTransform Set the one-to-one transform function.
+*/
+func (self *TransformerControlMessage) SetTransform(transform func(interfaces.IPipeMessage, interface{}) (interfaces.IPipeMessage, error)) {
+	self.transform = transform
+}
+
+/*
+Transform Get the one-to-one transform function.
+*/
+func (self *TransformerControlMessage) Transform() func(interfaces.IPipeMessage, interface{}) (interfaces.IPipeMessage, error) {
+	return self.transform
+}
+
+/*
+SetExpand Set the one-to-many transform function.
+*/
+func (self *TransformerControlMessage) SetExpand(expand func(interfaces.IPipeMessage, interface{}) ([]interfaces.IPipeMessage, error)) {
+	self.expand = expand
+}
+
+/*
+Expand Get the one-to-many transform function.
+*/
+func (self *TransformerControlMessage) Expand() func(interfaces.IPipeMessage, interface{}) ([]interfaces.IPipeMessage, error) {
+	return self.expand
+}
+
+/*
+SetParams Set the parameters object.
+*/
+func (self *TransformerControlMessage) SetParams(params interface{}) {
+	self.params = params
+}
+
+/*
+Params Get the parameters object.
+*/
+func (self *TransformerControlMessage) Params() interface{} {
+	return self.params
+}
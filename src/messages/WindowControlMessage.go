@@ -0,0 +1,115 @@
+//
+//  WindowControlMessage.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package messages
+
+import "time"
+
+const (
+	SET_WINDOW = "http://puremvc.org/namespaces/pipes/messages/normal/window-control/setWindow" // Set window kind and parameters.
+)
+
+/*
+WindowControlMessage Window Control Message.
+
+A special message type for changing the windowing of a Window
+at runtime.
+
+The messages.SET_WINDOW message type tells the Window to close
+its open windows, then retrieve the window kind, size, slide
+and gap and use them for subsequent messages.
+
+The Window only acts on a control message if it is targeted
+to this named window instance. Otherwise it writes the
+message through to its output unchanged.
+*/
+type WindowControlMessage struct {
+	Message
+	name  string
+	kind  string
+	size  time.Duration
+	slide time.Duration
+	gap   time.Duration
+}
+
+/*
+NewWindowControlMessage Constructor
+*/
+func NewWindowControlMessage(_type string, name string, kind string, size time.Duration, slide time.Duration, gap time.Duration) *WindowControlMessage {
+	return &WindowControlMessage{Message: Message{_type: _type, priority: PRIORITY_MED}, name: name, kind: kind, size: size, slide: slide, gap: gap}
+}
+
+/*
+SetName Set the target window name.
+*/
+func (self *WindowControlMessage) SetName(name string) {
+	self.name = name
+}
+
+/*
+Name Get the target window name.
+*/
+func (self *WindowControlMessage) Name() string {
+	return self.name
+}
+
+/*
+SetKind Set the kind of window.
+*/
+func (self *WindowControlMessage) SetKind(kind string) {
+	self.kind = kind
+}
+
+/*
+Kind Get the kind of window.
+*/
+func (self *WindowControlMessage) Kind() string {
+	return self.kind
+}
+
+/*
+SetSize Set the length of tumbling and sliding windows.
+*/
+func (self *WindowControlMessage) SetSize(size time.Duration) {
+	self.size = size
+}
+
+/*
+Size Get the length of tumbling and sliding windows.
+*/
+func (self *WindowControlMessage) Size() time.Duration {
+	return self.size
+}
+
+/*
+SetSlide Set the interval between the starts of sliding windows.
+*/
+func (self *WindowControlMessage) SetSlide(slide time.Duration) {
+	self.slide = slide
+}
+
+/*
+Slide Get the interval between the starts of sliding windows.
+*/
+func (self *WindowControlMessage) Slide() time.Duration {
+	return self.slide
+}
+
+/*
+SetGap Set the inactivity that closes a session window.
+*/
+func (self *WindowControlMessage) SetGap(gap time.Duration) {
+	self.gap = gap
+}
+
+/*
+Gap Get the inactivity that closes a session window.
+*/
+func (self *WindowControlMessage) Gap() time.Duration {
+	return self.gap
+}
@@ -0,0 +1,201 @@
+//
+//  Aggregator.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+	"time"
+)
+
+/*
+Aggregator Pipe Aggregator.
+
+Collects normal messages into batches and writes each batch
+to the output as a single normal message whose body is the
+[]interfaces.IPipeMessage of collected messages, in the order
+they were received. A Splitter expands such a batch back into
+individual messages.
+
+A batch is written when it holds MaxCount messages, when the
+Size of its messages adds up to MaxBytes, when MaxAge has
+passed since its first message, or when the Complete function
+reports it complete. Thresholds left at zero are not applied.
+
+If a Correlation function is set, messages are collected in a
+separate batch per correlation key, and the batch message has
+the key as its header.
+
+A messages.FLUSH control message writes out all pending batches,
+then is written through to the output. All other control
+messages are written through unchanged.
+*/
+type Aggregator struct {
+	Pipe
+	MaxCount    int                                                    // Messages per batch, 0 for no limit
+	MaxBytes    int                                                    // Total Size of the messages per batch, 0 for no limit
+	MaxAge      time.Duration                                          // Time from the first message to writing the batch, 0 for no limit
+	Size        func(message interfaces.IPipeMessage) int              // Size of a message, nil for the length of a []byte or string body
+	Correlation func(message interfaces.IPipeMessage) string           // Optional grouping of messages into batches
+	Complete    func(key string, batch []interfaces.IPipeMessage) bool // Optional test whether a batch is complete
+	Clock       Clock                                                  // Time source, nil for the system clock
+
+	batches map[string]*batch
+	keys    []string
+	mutex   sync.Mutex
+}
+
+type batch struct {
+	messages []interfaces.IPipeMessage
+	bytes    int
+	timer    Timer
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are added to their batch, and the batch is
+written to the output if it is full or complete.
+
+The messages.FLUSH message type writes all pending batches
+out immediately, then is written through to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if writing a batch or control message to the output failed.
+*/
+func (self *Aggregator) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Collect normal messages
+		if full := self.Collect(message); full != nil {
+			success = self.Output.Write(full)
+		}
+	case messages.FLUSH: // Write out pending batches, then let the flush through
+		success = self.Flush()
+		if self.Output.Write(message) == false {
+			success = false
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+/*
+Collect a message into its batch.
+
+- parameter message: the IPipeMessage to collect.
+
+- returns: IPipeMessage the batch message if the batch is now full or complete, otherwise nil.
+*/
+func (self *Aggregator) Collect(message interfaces.IPipeMessage) interfaces.IPipeMessage {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	key := ""
+	if self.Correlation != nil {
+		key = self.Correlation(message)
+	}
+	if self.batches == nil {
+		self.batches = make(map[string]*batch)
+	}
+
+	current, ok := self.batches[key]
+	if !ok {
+		current = &batch{}
+		self.batches[key] = current
+		self.keys = append(self.keys, key)
+		if self.MaxAge > 0 {
+			current.timer = clockOrSystem(self.Clock).AfterFunc(self.MaxAge, func() { self.expire(key, current) })
+		}
+	}
+	current.messages = append(current.messages, message)
+	current.bytes += self.size(message)
+
+	if (self.MaxCount > 0 && len(current.messages) >= self.MaxCount) ||
+		(self.MaxBytes > 0 && current.bytes >= self.MaxBytes) ||
+		(self.Complete != nil && self.Complete(key, current.messages)) {
+		return self.remove(key)
+	}
+	return nil
+}
+
+/*
+Flush all pending batches to the output.
+
+- returns: Bool true if all batches written successfully.
+*/
+func (self *Aggregator) Flush() bool {
+	self.mutex.Lock()
+	var released []interfaces.IPipeMessage
+	for len(self.keys) > 0 {
+		released = append(released, self.remove(self.keys[0]))
+	}
+	self.mutex.Unlock()
+
+	success := true
+	for _, message := range released {
+		if self.Output.Write(message) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+// expire Write out a batch whose MaxAge has passed, unless it was already written.
+func (self *Aggregator) expire(key string, expired *batch) {
+	self.mutex.Lock()
+	if self.batches[key] != expired {
+		self.mutex.Unlock()
+		return
+	}
+	message := self.remove(key)
+	self.mutex.Unlock()
+
+	self.Output.Write(message)
+}
+
+// remove Remove the batch of a key, returning its batch message. Caller holds the mutex.
+func (self *Aggregator) remove(key string) interfaces.IPipeMessage {
+	removed := self.batches[key]
+	if removed.timer != nil {
+		removed.timer.Stop()
+	}
+	delete(self.batches, key)
+	for index, pendingKey := range self.keys {
+		if pendingKey == key {
+			self.keys = append(self.keys[:index], self.keys[index+1:]...)
+			break
+		}
+	}
+
+	var header interface{}
+	if self.Correlation != nil {
+		header = key
+	}
+	return messages.NewMessage(messages.NORMAL, header, removed.messages, messages.PRIORITY_MED)
+}
+
+// size Get the size of a message.
+func (self *Aggregator) size(message interfaces.IPipeMessage) int {
+	if self.Size != nil {
+		return self.Size(message)
+	}
+	switch body := message.Body().(type) {
+	case []byte:
+		return len(body)
+	case string:
+		return len(body)
+	}
+	return 0
+}
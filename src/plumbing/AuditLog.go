@@ -0,0 +1,87 @@
+//
+//  AuditLog.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const AUDIT_LOG_LIMIT = 1000 // Denials an AuditLog keeps in memory unless its Limit is set
+
+/*
+PolicyDenial A message a JunctionPolicy denied.
+
+Only the type of the message is kept, not the message
+itself, so that the log holds on to neither the memory nor
+the possibly secret bodies of the messages it records.
+*/
+type PolicyDenial struct {
+	Time      time.Time     // When the message was denied
+	Pipe      string        // Name of the pipe the message was sent on or delivered from
+	Direction PipeDirection // OUTPUT when sending, INPUT when delivering
+	Type      string        // Type of the message
+	Sender    string        // Identity of the sender, empty if none
+	Reason    string        // Why the policy denied it
+}
+
+/*
+AuditLog Record of the messages denied by the policy of a Junction.
+
+Keeps the most recent denials in memory, and writes a line
+for each to the Writer, if set. Safe to share between
+junctions.
+*/
+type AuditLog struct {
+	Writer io.Writer // Destination of a line per denial, nil for none
+	Limit  int       // Number of denials kept in memory, 0 for AUDIT_LOG_LIMIT
+	Clock  Clock     // Time source, nil for the system clock
+
+	denials []PolicyDenial
+	mutex   sync.Mutex
+}
+
+/*
+Record a denial.
+
+- parameter request: the PolicyRequest denied
+
+- parameter reason: why the policy denied it
+*/
+func (self *AuditLog) Record(request PolicyRequest, reason string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	denial := PolicyDenial{Time: clockOrSystem(self.Clock).Now(), Pipe: request.Pipe, Direction: request.Direction,
+		Type: request.Message.Type(), Sender: request.Sender, Reason: reason}
+	self.denials = append(self.denials, denial)
+	limit := self.Limit
+	if limit <= 0 {
+		limit = AUDIT_LOG_LIMIT
+	}
+	if len(self.denials) > limit {
+		self.denials = self.denials[len(self.denials)-limit:]
+	}
+	if self.Writer != nil {
+		fmt.Fprintf(self.Writer, "%s denied %s message on %s pipe %q from sender %q: %s\n",
+			denial.Time.Format(time.RFC3339Nano), denial.Type, denial.Direction, denial.Pipe, denial.Sender, reason)
+	}
+}
+
+/*
+Denials Get the denials kept in memory, oldest first.
+*/
+func (self *AuditLog) Denials() []PolicyDenial {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return append([]PolicyDenial{}, self.denials...)
+}
@@ -0,0 +1,252 @@
+//
+//  CircuitBreaker.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+	"time"
+)
+
+const (
+	CIRCUIT_CLOSED    = "closed"    // Normal messages are written to the output
+	CIRCUIT_OPEN      = "open"      // Normal messages are short-circuited
+	CIRCUIT_HALF_OPEN = "half-open" // A single trial message is written to the output
+)
+
+/*
+CircuitBreaker Pipe Circuit Breaker.
+
+Protects a pipeline from an output that keeps failing. While
+closed, normal messages are written to the output and the
+outcome of each write is recorded. When the number of
+consecutive failures reaches FailureThreshold, or the ratio
+of failures over the last WindowSize writes reaches
+FailureRatio, the breaker opens.
+
+While open, normal messages are not written to the output.
+They are written to the Fallback fitting instead if there
+is one, otherwise the write fails immediately. Once
+OpenTimeout has elapsed the breaker goes half-open and lets
+a single trial message through: if it succeeds the breaker
+closes, if it fails the breaker opens again.
+
+The breaker can be forced open or closed, or reset to
+automatic operation, by CircuitBreakerControlMessages
+addressed to its Name. All other messages are written
+through to the output unchanged.
+*/
+type CircuitBreaker struct {
+	Pipe
+	Name             string
+	Fallback         interfaces.IPipeFitting // Optional fitting to divert normal messages to while open
+	FailureThreshold int                     // Consecutive failures that open the breaker, 0 to disable
+	FailureRatio     float64                 // Failure ratio over the window that opens the breaker, 0 to disable
+	WindowSize       int                     // Number of most recent writes the failure ratio is computed over
+	OpenTimeout      time.Duration           // How long to stay open before letting a trial message through
+	Clock            Clock                   // Time source, nil for the system clock
+
+	state    string
+	forced   bool
+	trial    bool
+	failures int
+	window   []bool
+	openedAt time.Time
+	mutex    sync.Mutex
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are written to the output unless the
+breaker is open, in which case they are written to the
+Fallback fitting if any.
+
+The messages.BREAKER_OPEN, messages.BREAKER_CLOSE and
+messages.BREAKER_RESET message types change the state of
+the breaker if they are targeted to this named instance.
+Otherwise, they are written through to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean true if the message was written successfully
+to the output, or to the fallback while open.
+*/
+func (self *CircuitBreaker) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Guard normal messages
+		if self.allow() {
+			success = self.Output.Write(message)
+			self.record(success)
+		} else if self.Fallback != nil {
+			success = self.Fallback.Write(message)
+		} else {
+			success = false
+		}
+	case messages.BREAKER_OPEN:
+		fallthrough
+	case messages.BREAKER_CLOSE:
+		fallthrough
+	case messages.BREAKER_RESET:
+		if _, ok := message.(*messages.CircuitBreakerControlMessage); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			self.control(message.Type())
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "CircuitBreaker", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+// IsTarget Is the message directed at this circuit breaker instance?
+func (self *CircuitBreaker) IsTarget(message interfaces.IPipeMessage) bool {
+	control, ok := message.(*messages.CircuitBreakerControlMessage)
+	return ok && control.Name() == self.Name
+}
+
+/*
+State Get the current state of the breaker.
+
+- returns: string CIRCUIT_CLOSED, CIRCUIT_OPEN or CIRCUIT_HALF_OPEN
+*/
+func (self *CircuitBreaker) State() string {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.currentState()
+}
+
+// currentState Get the state, moving from open to half-open once the timeout elapsed. Caller holds the mutex.
+func (self *CircuitBreaker) currentState() string {
+	if self.state == "" {
+		self.state = CIRCUIT_CLOSED
+	}
+	if self.state == CIRCUIT_OPEN && !self.forced &&
+		!clockOrSystem(self.Clock).Now().Before(self.openedAt.Add(self.OpenTimeout)) {
+		self.state = CIRCUIT_HALF_OPEN
+	}
+	return self.state
+}
+
+// allow Decide whether a normal message may be written to the output.
+func (self *CircuitBreaker) allow() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	switch self.currentState() {
+	case CIRCUIT_CLOSED:
+		return true
+	case CIRCUIT_HALF_OPEN:
+		if !self.trial { // only one trial message at a time
+			self.trial = true
+			return true
+		}
+	}
+	return false
+}
+
+// record Record the outcome of a write to the output.
+func (self *CircuitBreaker) record(success bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.forced {
+		return
+	}
+
+	if self.WindowSize > 0 {
+		self.window = append(self.window, success)
+		if len(self.window) > self.WindowSize {
+			self.window = self.window[1:]
+		}
+	}
+
+	if success {
+		self.failures = 0
+		if self.state == CIRCUIT_HALF_OPEN {
+			self.close()
+		}
+		return
+	}
+
+	self.failures++
+	if self.state == CIRCUIT_HALF_OPEN || self.tripped() {
+		self.open()
+	}
+}
+
+// tripped Have the failure statistics crossed a threshold? Caller holds the mutex.
+func (self *CircuitBreaker) tripped() bool {
+	if self.FailureThreshold > 0 && self.failures >= self.FailureThreshold {
+		return true
+	}
+	if self.FailureRatio > 0 && self.WindowSize > 0 && len(self.window) >= self.WindowSize {
+		failed := 0
+		for _, ok := range self.window {
+			if !ok {
+				failed++
+			}
+		}
+		return float64(failed)/float64(len(self.window)) >= self.FailureRatio
+	}
+	return false
+}
+
+// control Apply a control message type to the breaker.
+func (self *CircuitBreaker) control(_type string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	switch _type {
+	case messages.BREAKER_OPEN:
+		self.open()
+		self.forced = true
+	case messages.BREAKER_CLOSE:
+		self.close()
+		self.forced = true
+	case messages.BREAKER_RESET:
+		self.close()
+		self.forced = false
+	}
+}
+
+// open Open the breaker. Caller holds the mutex.
+func (self *CircuitBreaker) open() {
+	self.state = CIRCUIT_OPEN
+	self.openedAt = clockOrSystem(self.Clock).Now()
+	self.trial = false
+}
+
+// close Close the breaker and discard failure statistics. Caller holds the mutex.
+func (self *CircuitBreaker) close() {
+	self.state = CIRCUIT_CLOSED
+	self.failures = 0
+	self.window = nil
+	self.trial = false
+}
+
+/*
+Status Get the state of the breaker.
+*/
+func (self *CircuitBreaker) Status() map[string]interface{} {
+	return map[string]interface{}{"state": self.State()}
+}
@@ -0,0 +1,59 @@
+//
+//  Clock.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import "time"
+
+/*
+Clock Time source for time-driven fittings.
+
+Fittings that depend on the passage of time read the
+current time and schedule work through a Clock, so that
+a fake implementation can be injected to make their
+behavior deterministic under test. A nil Clock on any
+fitting means the system clock.
+*/
+type Clock interface {
+	Now() time.Time                                   // Get the current time
+	AfterFunc(duration time.Duration, f func()) Timer // Call f in its own goroutine after duration has elapsed
+}
+
+/*
+Timer A pending call scheduled on a Clock.
+*/
+type Timer interface {
+	Stop() bool // Prevent the call from firing, returns false if it already fired or was stopped
+}
+
+/*
+SystemClock Clock backed by the time package.
+*/
+type SystemClock struct{}
+
+/*
+Now Get the current local time.
+*/
+func (self SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+/*
+AfterFunc Call f in its own goroutine after duration has elapsed.
+*/
+func (self SystemClock) AfterFunc(duration time.Duration, f func()) Timer {
+	return time.AfterFunc(duration, f)
+}
+
+// clockOrSystem Get the given clock, or the system clock if nil.
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock{}
+	}
+	return clock
+}
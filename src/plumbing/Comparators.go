@@ -0,0 +1,163 @@
+//
+//  Comparators.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"fmt"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"strings"
+	"time"
+)
+
+/*
+Comparator An ordering of messages, as used by the Queue in sort mode.
+
+Returns a negative number if a comes before b, a positive
+number if b comes before a, and zero if their order does not
+matter, in which case the Queue keeps them in the order they
+arrived.
+*/
+type Comparator = func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int
+
+/*
+ByPriority Order messages by priority, highest (lowest number) first.
+
+The default ordering of the Queue in sort mode.
+*/
+func ByPriority(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int {
+	return a.Priority() - b.Priority()
+}
+
+/*
+ByDeadline Order messages by the time.Time in their messages.METADATA_DEADLINE metadata, earliest first.
+
+Messages without a deadline come last.
+*/
+func ByDeadline(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int {
+	return compareKeys(messages.Metadata(a, messages.METADATA_DEADLINE), messages.Metadata(b, messages.METADATA_DEADLINE))
+}
+
+/*
+ByAge Order messages by the time.Time in their messages.METADATA_TIMESTAMP metadata, oldest first.
+
+Messages without a timestamp come last.
+*/
+func ByAge(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int {
+	return compareKeys(messages.Metadata(a, messages.METADATA_TIMESTAMP), messages.Metadata(b, messages.METADATA_TIMESTAMP))
+}
+
+/*
+ByKey Order messages by a key taken from each message, smallest first.
+
+Numbers of any type compare numerically, strings lexically
+and time.Time values chronologically. Messages whose key is
+nil, or not comparable to the key of the other message, come
+last.
+
+- parameter key: get the key of a message
+
+- returns: the Comparator.
+*/
+func ByKey(key func(message interfaces.IPipeMessage) interface{}) Comparator {
+	return func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int {
+		return compareKeys(key(a), key(b))
+	}
+}
+
+/*
+ByField Order messages by the value at a path, smallest first.
+
+The path is as in an Expression, e.g. header.deadline or
+metadata.tenant, so that an ordering can be configured from
+text. Values compare as in ByKey.
+
+- parameter path: the path of the value to order by
+
+- returns: the Comparator, or an *ExpressionError if the path is not valid.
+*/
+func ByField(path string) (Comparator, error) {
+	expression, err := CompileExpression(path)
+	if err != nil {
+		return nil, err
+	}
+	field, ok := expression.root.(*pathNode)
+	if !ok {
+		return nil, &ExpressionError{Position: 0, Message: fmt.Sprintf("%q is not a path", path)}
+	}
+	return ByKey(func(message interfaces.IPipeMessage) interface{} {
+		return field.value(message, nil)
+	}), nil
+}
+
+/*
+Composite Order messages by the first comparator that tells them apart.
+
+For example Composite(ByPriority, ByAge) orders by priority,
+then oldest first among messages of the same priority.
+*/
+func Composite(comparators ...Comparator) Comparator {
+	return func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int {
+		for _, comparator := range comparators {
+			if order := comparator(a, b); order != 0 {
+				return order
+			}
+		}
+		return 0
+	}
+}
+
+/*
+Reverse Invert a comparator.
+*/
+func Reverse(comparator Comparator) Comparator {
+	return func(a interfaces.IPipeMessage, b interfaces.IPipeMessage) int {
+		return comparator(b, a)
+	}
+}
+
+// compareKeys Compare two keys, putting nil and incomparable keys last.
+func compareKeys(a interface{}, b interface{}) int {
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return at.Compare(bt)
+		}
+		return -1
+	}
+	if _, ok := b.(time.Time); ok {
+		return 1
+	}
+
+	a, b = normalize(a), normalize(b)
+	switch l := a.(type) {
+	case float64:
+		if r, ok := b.(float64); ok {
+			if l < r {
+				return -1
+			} else if l > r {
+				return 1
+			}
+			return 0
+		}
+		return -1
+	case string:
+		if r, ok := b.(string); ok {
+			return strings.Compare(l, r)
+		}
+		if _, ok := b.(float64); ok {
+			return 1
+		}
+		return -1
+	}
+	switch b.(type) {
+	case float64, string:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,173 @@
+//
+//  Debounce.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+	"time"
+)
+
+const (
+	DEBOUNCE_LATEST   = "latest"   // Keep only the latest pending message per key (default behavior)
+	DEBOUNCE_COALESCE = "coalesce" // Merge pending messages per key with the Merge function
+)
+
+/*
+Debounce Pipe Debounce.
+
+Holds bursts of normal messages back and writes a single
+message per key to the output once no new message for that
+key has arrived for the Quiet period.
+
+In DEBOUNCE_LATEST mode the message written is the latest one
+received for the key. In DEBOUNCE_COALESCE mode each message
+received is merged into the pending one with the Merge function,
+and the merged result is written.
+
+Messages are grouped by the Key function, or all together if
+there is none.
+
+A messages.FLUSH control message writes out all pending messages
+at once, in the order their keys were first held, then is written
+through to the output so that later fittings can flush as well.
+All other control messages are written through unchanged.
+*/
+type Debounce struct {
+	Pipe
+	Quiet time.Duration                                // Time without new messages before a key is written out
+	Mode  string                                       // DEBOUNCE_LATEST or DEBOUNCE_COALESCE
+	Key   func(message interfaces.IPipeMessage) string // Optional grouping of messages, nil for a single group
+	// Merge a message into the pending one in DEBOUNCE_COALESCE mode
+	Merge func(pending interfaces.IPipeMessage, message interfaces.IPipeMessage) interfaces.IPipeMessage
+	Clock Clock // Time source, nil for the system clock
+
+	pending map[string]*debounced
+	keys    []string
+	mutex   sync.Mutex
+}
+
+type debounced struct {
+	message    interfaces.IPipeMessage
+	timer      Timer
+	generation int
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are held until their key has been quiet
+for the Quiet period.
+
+The messages.FLUSH message type writes all held messages
+out immediately, then is written through to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean true unless a write to the output failed.
+*/
+func (self *Debounce) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Hold normal messages
+		self.Hold(message)
+	case messages.FLUSH: // Write out held messages, then let the flush through
+		success = self.Flush()
+		if self.Output.Write(message) == false {
+			success = false
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+/*
+Hold a message until its key has been quiet for the Quiet period.
+
+- parameter message: the IPipeMessage to hold.
+*/
+func (self *Debounce) Hold(message interfaces.IPipeMessage) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	key := ""
+	if self.Key != nil {
+		key = self.Key(message)
+	}
+	if self.pending == nil {
+		self.pending = make(map[string]*debounced)
+	}
+
+	entry, ok := self.pending[key]
+	if !ok {
+		entry = &debounced{message: message}
+		self.pending[key] = entry
+		self.keys = append(self.keys, key)
+	} else {
+		entry.timer.Stop()
+		if self.Mode == DEBOUNCE_COALESCE && self.Merge != nil {
+			entry.message = self.Merge(entry.message, message)
+		} else {
+			entry.message = message
+		}
+	}
+
+	entry.generation++
+	generation := entry.generation
+	entry.timer = clockOrSystem(self.Clock).AfterFunc(self.Quiet, func() { self.release(key, entry, generation) })
+}
+
+/*
+Flush all held messages to the output.
+
+- returns: Bool true if all messages written successfully.
+*/
+func (self *Debounce) Flush() bool {
+	self.mutex.Lock()
+	var released []interfaces.IPipeMessage
+	for _, key := range self.keys {
+		entry := self.pending[key]
+		entry.timer.Stop()
+		released = append(released, entry.message)
+	}
+	self.pending = nil
+	self.keys = nil
+	self.mutex.Unlock()
+
+	success := true
+	for _, message := range released {
+		if self.Output.Write(message) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+// release Write out the held message of a key if it has not been held again since the timer was set.
+func (self *Debounce) release(key string, entry *debounced, generation int) {
+	self.mutex.Lock()
+	if self.pending[key] != entry || entry.generation != generation {
+		self.mutex.Unlock()
+		return
+	}
+	delete(self.pending, key)
+	for index, pendingKey := range self.keys {
+		if pendingKey == key {
+			self.keys = append(self.keys[:index], self.keys[index+1:]...)
+			break
+		}
+	}
+	self.mutex.Unlock()
+
+	self.Output.Write(entry.message)
+}
@@ -0,0 +1,150 @@
+//
+//  Decrypt.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+)
+
+/*
+Decrypt Pipe Decrypt.
+
+Restores the bodies of normal messages encrypted by an Encrypt
+fitting. The body is opened with the key whose ID the message
+carries, so a Decrypt given both the old and the new keys
+accepts messages across a key rotation, then deserialized by
+the Unmarshal function, or decoded from JSON without one, in
+which case objects come back as map[string]interface{} and
+numbers as float64.
+
+The restored body is written in a copy of the message made
+with messages.Copy, so that other branches of a pipeline
+sharing the message still see its ciphertext. Messages that
+are not encrypted, were encrypted with a key that is not in
+Keys, or whose ciphertext was tampered with, are not written.
+
+All other messages are written through unchanged.
+*/
+type Decrypt struct {
+	Pipe
+	Name      string
+	Keys      KeyProvider                                     // Source of the keys messages may be encrypted with
+	Unmarshal func(data []byte) (body interface{}, err error) // Deserialize a body, nil for JSON
+
+	decrypted int
+	failed    int
+	mutex     sync.Mutex
+}
+
+/*
+Write Handle the incoming message.
+
+The bodies of normal messages are decrypted, then the messages
+are written to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if the body could not be decrypted or
+the write to the output failed.
+*/
+func (self *Decrypt) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Decrypt the body of a copy of normal messages
+		opened := messages.Copy(message)
+		if self.Open(opened) == nil {
+			success = self.Output.Write(opened)
+		} else {
+			success = false
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Decrypt", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+/*
+Open Decrypt the body of a message, in place.
+
+The encryption key ID is cleared from the metadata of the message.
+
+- parameter message: the IPipeMessage to decrypt
+
+- returns: an error if the body could not be decrypted, in which case the message is unchanged.
+*/
+func (self *Decrypt) Open(message interfaces.IPipeMessage) error {
+	err := self.open(message)
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if err != nil {
+		self.failed++
+	} else {
+		self.decrypted++
+	}
+	return err
+}
+
+/*
+Status Get the number of messages decrypted and failed.
+*/
+func (self *Decrypt) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return map[string]interface{}{"decrypted": self.decrypted, "failed": self.failed}
+}
+
+// open Replace the ciphertext body of a message with the plaintext body.
+func (self *Decrypt) open(message interfaces.IPipeMessage) error {
+	keyID, ok := messages.Metadata(message, messages.METADATA_ENCRYPTION_KEY_ID).(string)
+	if !ok {
+		return errors.New("decrypt: message is not encrypted")
+	}
+	ciphertext, ok := message.Body().([]byte)
+	if !ok {
+		return errors.New("decrypt: body is not ciphertext")
+	}
+	aead, err := newAEAD(self.Keys, keyID)
+	if err != nil {
+		return err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return errors.New("decrypt: ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return err
+	}
+
+	var body interface{}
+	if self.Unmarshal != nil {
+		body, err = self.Unmarshal(plaintext)
+	} else {
+		err = json.Unmarshal(plaintext, &body)
+	}
+	if err != nil {
+		return err
+	}
+	message.SetBody(body)
+	messages.SetMetadata(message, messages.METADATA_ENCRYPTION_KEY_ID, nil)
+	return nil
+}
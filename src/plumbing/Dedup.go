@@ -0,0 +1,180 @@
+//
+//  Dedup.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+	"time"
+)
+
+/*
+DedupStore Persistent record of the keys a Dedup has seen.
+
+Lets a Dedup detect duplicates of messages it saw before a
+restart. Implementations must be safe for concurrent use.
+*/
+type DedupStore interface {
+	Seen(key string) (time.Time, bool)   // When the key was seen, and whether it was
+	Remember(key string, seen time.Time) // Record that the key was seen
+	Forget(key string)                   // Discard the record of the key
+}
+
+/*
+Dedup Pipe Deduplicator.
+
+An idempotent receiver: writes each normal message to the
+output only the first time its key is seen within the Window,
+and drops the duplicates. The key of a message is given by the
+Key function, or is its messages.METADATA_ID metadata if there
+is none. Messages without a key are always written out.
+
+Seen keys are kept in memory, up to Capacity keys with the
+oldest forgotten first. If a Store is set, keys are also
+recorded there and looked up when not found in memory, so
+that duplicates are still detected after a restart.
+
+If the output fails to take a message, its key is forgotten
+so that a retry of the message is not dropped.
+
+Control messages are written through unchanged.
+*/
+type Dedup struct {
+	Pipe
+	Key      func(message interfaces.IPipeMessage) string // Key of a message, nil for its message ID
+	Window   time.Duration                                // How long a key is remembered, 0 for as long as capacity allows
+	Capacity int                                          // Keys remembered in memory, 0 for no limit
+	Store    DedupStore                                   // Optional persistent record of seen keys
+	Clock    Clock                                        // Time source, nil for the system clock
+
+	seen  map[string]*list.Element
+	order *list.List
+	mutex sync.Mutex
+}
+
+type dedupEntry struct {
+	key  string
+	seen time.Time
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are written to the output unless their key
+was already seen within the window.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean true if the message was written successfully
+or dropped as a duplicate.
+*/
+func (self *Dedup) Write(message interfaces.IPipeMessage) bool {
+	if message.Type() != messages.NORMAL {
+		return self.Output.Write(message)
+	}
+
+	key := self.key(message)
+	if key == "" {
+		return self.Output.Write(message)
+	}
+	if self.IsDuplicate(key) {
+		return true
+	}
+
+	success := self.Output.Write(message)
+	if !success {
+		self.forget(key)
+	}
+	return success
+}
+
+/*
+IsDuplicate Check whether a key was seen within the window, remembering it if not.
+
+- parameter key: the message key
+
+- returns: Bool true if the key was already seen.
+*/
+func (self *Dedup) IsDuplicate(key string) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	now := clockOrSystem(self.Clock).Now()
+	self.expire(now)
+
+	if _, ok := self.seen[key]; ok {
+		return true
+	}
+	if self.Store != nil {
+		if seen, ok := self.Store.Seen(key); ok {
+			if self.Window <= 0 || now.Sub(seen) < self.Window {
+				return true
+			}
+		}
+	}
+
+	if self.seen == nil {
+		self.seen = make(map[string]*list.Element)
+		self.order = list.New()
+	}
+	self.seen[key] = self.order.PushBack(&dedupEntry{key: key, seen: now})
+	if self.Capacity > 0 && self.order.Len() > self.Capacity {
+		self.remove(self.order.Front())
+	}
+	if self.Store != nil {
+		self.Store.Remember(key, now)
+	}
+	return false
+}
+
+// forget Discard a remembered key.
+func (self *Dedup) forget(key string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if element, ok := self.seen[key]; ok {
+		self.remove(element)
+	}
+	if self.Store != nil {
+		self.Store.Forget(key)
+	}
+}
+
+// expire Forget keys seen longer ago than the window. Caller holds the mutex.
+func (self *Dedup) expire(now time.Time) {
+	if self.Window <= 0 || self.order == nil {
+		return
+	}
+	for front := self.order.Front(); front != nil; front = self.order.Front() {
+		if now.Sub(front.Value.(*dedupEntry).seen) < self.Window {
+			break
+		}
+		self.remove(front)
+	}
+}
+
+// remove Remove a key from memory. Caller holds the mutex.
+func (self *Dedup) remove(element *list.Element) {
+	delete(self.seen, element.Value.(*dedupEntry).key)
+	self.order.Remove(element)
+}
+
+// key Get the key of a message.
+func (self *Dedup) key(message interfaces.IPipeMessage) string {
+	if self.Key != nil {
+		return self.Key(message)
+	}
+	if id := messages.Metadata(message, messages.METADATA_ID); id != nil {
+		return fmt.Sprint(id)
+	}
+	return ""
+}
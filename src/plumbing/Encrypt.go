@@ -0,0 +1,173 @@
+//
+//  Encrypt.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+)
+
+/*
+Encrypt Pipe Encrypt.
+
+Encrypts the bodies of normal messages, so that secrets can be
+passed through the cores of third-party modules, to be restored
+by a Decrypt fitting on the other side.
+
+The body is serialized by the Marshal function, or encoded as
+JSON without one, then sealed with AES-GCM under the key of
+KeyID from Keys, which must be 16, 24 or 32 bytes long for
+AES-128, AES-192 or AES-256. The body is replaced by the
+[]byte of a random nonce followed by the ciphertext, and the
+key ID is stored in the messages.METADATA_ENCRYPTION_KEY_ID
+metadata, of a copy of the message made with messages.Copy, so
+that other branches of a pipeline sharing the message still see
+its plaintext. The header, priority and other metadata are left
+in the clear for the fittings along the way. Messages that
+cannot be encrypted are not written.
+
+All other messages, such as messages.FLUSH or messages.FILTER,
+are written through unchanged, so they still reach the
+fittings downstream.
+*/
+type Encrypt struct {
+	Pipe
+	Name    string
+	Keys    KeyProvider                            // Source of the encryption keys
+	KeyID   string                                 // ID of the key to encrypt with
+	Marshal func(body interface{}) ([]byte, error) // Serialize a body, nil for JSON
+
+	encrypted int
+	failed    int
+	mutex     sync.Mutex
+}
+
+/*
+Write Handle the incoming message.
+
+The bodies of normal messages are encrypted, then the messages
+are written to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if the body could not be encrypted or
+the write to the output failed.
+*/
+func (self *Encrypt) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Encrypt the body of a copy of normal messages
+		sealed := messages.Copy(message)
+		if self.Seal(sealed) == nil {
+			success = self.Output.Write(sealed)
+		} else {
+			success = false
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Encrypt", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+/*
+Seal Encrypt the body of a message with the current key, in place.
+
+- parameter message: the IPipeMessage to encrypt
+
+- returns: an error if the body could not be encrypted, in which case the message is unchanged.
+*/
+func (self *Encrypt) Seal(message interfaces.IPipeMessage) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	err := self.seal(message)
+	if err != nil {
+		self.failed++
+	} else {
+		self.encrypted++
+	}
+	return err
+}
+
+/*
+Rotate Encrypt subsequent messages with another key.
+
+- parameter keyID: the ID of the key to encrypt with
+*/
+func (self *Encrypt) Rotate(keyID string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.KeyID = keyID
+}
+
+/*
+Status Get the ID of the encryption key, and the number of messages encrypted and failed.
+*/
+func (self *Encrypt) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return map[string]interface{}{"keyID": self.KeyID, "encrypted": self.encrypted, "failed": self.failed}
+}
+
+// seal Replace the body of a message with its ciphertext. Caller holds the mutex.
+func (self *Encrypt) seal(message interfaces.IPipeMessage) error {
+	aead, err := newAEAD(self.Keys, self.KeyID)
+	if err != nil {
+		return err
+	}
+	marshal := self.Marshal
+	if marshal == nil {
+		marshal = json.Marshal
+	}
+	plaintext, err := marshal(message.Body())
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if !messages.SetMetadata(message, messages.METADATA_ENCRYPTION_KEY_ID, self.KeyID) {
+		return errors.New("encrypt: message has no metadata")
+	}
+	message.SetBody(aead.Seal(nonce, nonce, plaintext, []byte(self.KeyID)))
+	return nil
+}
+
+// newAEAD Create the AES-GCM cipher for the key with an ID.
+func newAEAD(keys KeyProvider, keyID string) (cipher.AEAD, error) {
+	if keys == nil {
+		return nil, errors.New("cipher: no keys")
+	}
+	key, ok := keys.Key(keyID)
+	if !ok {
+		return nil, errors.New("cipher: unknown key ID " + keyID)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
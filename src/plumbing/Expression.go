@@ -0,0 +1,523 @@
+//
+//  Expression.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"fmt"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+Expression Compiled Filter Expression.
+
+A small, safe expression language for filtering messages,
+so that filters can be configured from text, e.g.
+
+	priority <= 5 && header.kind == "alert"
+
+Values are read from the message by path. A path starts at
+type, priority, header, body, metadata or params (the
+parameters of the filter), and each following segment selects
+a map key, an exported struct field, or a slice index, e.g.
+body.items.0.name. A path that leads nowhere, or to a value
+that is not a number, string or boolean, is null.
+
+Literals are numbers, "strings" with Go escapes, true, false
+and null. Values are compared with == != < <= > >=, where
+numbers of any type compare numerically and strings compare
+lexically, and combined with && || ! and parentheses. Values
+of different types are never equal and have no order.
+
+Evaluating an expression never panics. A message matches only
+if the expression evaluates to true.
+*/
+type Expression struct {
+	source string
+	root   expressionNode
+}
+
+/*
+ExpressionError An error compiling an expression.
+*/
+type ExpressionError struct {
+	Position int    // Byte offset in the source where the error was found
+	Message  string // Description of the error
+}
+
+func (self *ExpressionError) Error() string {
+	return fmt.Sprintf("expression: %s at position %d", self.Message, self.Position)
+}
+
+/*
+CompileExpression Parse an expression.
+
+- parameter source: the text of the expression
+
+- returns: the compiled *Expression, or an *ExpressionError if the source is not a valid expression.
+*/
+func CompileExpression(source string) (*Expression, error) {
+	parser := &expressionParser{source: source}
+	if err := parser.scan(); err != nil {
+		return nil, err
+	}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != tokenEnd {
+		return nil, &ExpressionError{Position: token.position, Message: fmt.Sprintf("unexpected %q", token.text)}
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+/*
+Match Evaluate the expression against a message.
+
+Usable as the filter function of a Filter or FilterChain.
+
+- parameter message: the IPipeMessage to evaluate
+
+- parameter params: the value of the params path
+
+- returns: Bool true if the expression evaluates to true.
+*/
+func (self *Expression) Match(message interfaces.IPipeMessage, params interface{}) bool {
+	result, ok := self.root.evaluate(message, params).(bool)
+	return ok && result
+}
+
+/*
+String Get the source of the expression.
+*/
+func (self *Expression) String() string {
+	return self.source
+}
+
+const (
+	tokenEnd = iota
+	tokenPath
+	tokenNumber
+	tokenString
+	tokenOperator
+)
+
+type expressionToken struct {
+	kind     int
+	text     string
+	position int
+}
+
+const maxExpressionDepth = 64 // Nesting of parentheses and negations allowed in an expression
+
+type expressionParser struct {
+	source string
+	tokens []expressionToken
+	next   int
+	depth  int
+}
+
+// scan Split the source into tokens.
+func (self *expressionParser) scan() error {
+	source := self.source
+	for position := 0; position < len(source); {
+		char := source[position]
+		switch {
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r':
+			position++
+		case isIdentifierStart(char):
+			end := position
+			for end < len(source) && (isIdentifierPart(source[end]) || source[end] == '.' && end+1 < len(source) && isIdentifierPart(source[end+1])) {
+				end++
+			}
+			self.tokens = append(self.tokens, expressionToken{kind: tokenPath, text: source[position:end], position: position})
+			position = end
+		case char >= '0' && char <= '9' || char == '-' && position+1 < len(source) && source[position+1] >= '0' && source[position+1] <= '9':
+			end := position + 1
+			for end < len(source) && (source[end] >= '0' && source[end] <= '9' || source[end] == '.' || source[end] == 'e' || source[end] == 'E') {
+				end++
+			}
+			self.tokens = append(self.tokens, expressionToken{kind: tokenNumber, text: source[position:end], position: position})
+			position = end
+		case char == '"':
+			end := position + 1
+			for end < len(source) && source[end] != '"' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return &ExpressionError{Position: position, Message: "unterminated string"}
+			}
+			self.tokens = append(self.tokens, expressionToken{kind: tokenString, text: source[position : end+1], position: position})
+			position = end + 1
+		default:
+			operator := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(source[position:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return &ExpressionError{Position: position, Message: fmt.Sprintf("unexpected character %q", char)}
+			}
+			self.tokens = append(self.tokens, expressionToken{kind: tokenOperator, text: operator, position: position})
+			position += len(operator)
+		}
+	}
+	self.tokens = append(self.tokens, expressionToken{kind: tokenEnd, text: "end of expression", position: len(source)})
+	return nil
+}
+
+// peek Get the next token without consuming it.
+func (self *expressionParser) peek() expressionToken {
+	return self.tokens[self.next]
+}
+
+// accept Consume the next token if it is the given operator.
+func (self *expressionParser) accept(operator string) bool {
+	if token := self.peek(); token.kind == tokenOperator && token.text == operator {
+		self.next++
+		return true
+	}
+	return false
+}
+
+// nest Enter a nested expression, failing if it is nested too deeply.
+func (self *expressionParser) nest() error {
+	self.depth++
+	if self.depth > maxExpressionDepth {
+		return &ExpressionError{Position: self.tokens[self.next-1].position, Message: "expression nested too deeply"}
+	}
+	return nil
+}
+
+// unnest Leave a nested expression.
+func (self *expressionParser) unnest() {
+	self.depth--
+}
+
+// parseOr or := and ('||' and)*
+func (self *expressionParser) parseOr() (expressionNode, error) {
+	left, err := self.parseAnd()
+	for err == nil && self.accept("||") {
+		var right expressionNode
+		if right, err = self.parseAnd(); err == nil {
+			left = &logicalNode{or: true, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+// parseAnd and := not ('&&' not)*
+func (self *expressionParser) parseAnd() (expressionNode, error) {
+	left, err := self.parseNot()
+	for err == nil && self.accept("&&") {
+		var right expressionNode
+		if right, err = self.parseNot(); err == nil {
+			left = &logicalNode{or: false, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+// parseNot not := '!' not | comparison
+func (self *expressionParser) parseNot() (expressionNode, error) {
+	if self.accept("!") {
+		if err := self.nest(); err != nil {
+			return nil, err
+		}
+		defer self.unnest()
+		operand, err := self.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return self.parseComparison()
+}
+
+// parseComparison comparison := operand (operator operand)?
+func (self *expressionParser) parseComparison() (expressionNode, error) {
+	left, err := self.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if self.accept(operator) {
+			right, err := self.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &comparisonNode{operator: operator, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+// parseOperand operand := literal | path | '(' or ')'
+func (self *expressionParser) parseOperand() (expressionNode, error) {
+	token := self.peek()
+	switch token.kind {
+	case tokenNumber:
+		self.next++
+		number, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, &ExpressionError{Position: token.position, Message: fmt.Sprintf("invalid number %q", token.text)}
+		}
+		return &literalNode{value: number}, nil
+	case tokenString:
+		self.next++
+		text, err := strconv.Unquote(token.text)
+		if err != nil {
+			return nil, &ExpressionError{Position: token.position, Message: fmt.Sprintf("invalid string %s", token.text)}
+		}
+		return &literalNode{value: text}, nil
+	case tokenPath:
+		self.next++
+		switch token.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		segments := strings.Split(token.text, ".")
+		switch segments[0] {
+		case "type", "priority":
+			if len(segments) > 1 {
+				return nil, &ExpressionError{Position: token.position, Message: fmt.Sprintf("%s has no fields", segments[0])}
+			}
+		case "header", "body", "metadata", "params":
+		default:
+			return nil, &ExpressionError{Position: token.position, Message: fmt.Sprintf("unknown path %q", token.text)}
+		}
+		return &pathNode{segments: segments}, nil
+	case tokenOperator:
+		if self.accept("(") {
+			if err := self.nest(); err != nil {
+				return nil, err
+			}
+			defer self.unnest()
+			inner, err := self.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !self.accept(")") {
+				closing := self.peek()
+				return nil, &ExpressionError{Position: closing.position, Message: fmt.Sprintf("expected ) but found %q", closing.text)}
+			}
+			return inner, nil
+		}
+	}
+	return nil, &ExpressionError{Position: token.position, Message: fmt.Sprintf("expected a value but found %q", token.text)}
+}
+
+func isIdentifierStart(char byte) bool {
+	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char == '_'
+}
+
+func isIdentifierPart(char byte) bool {
+	return isIdentifierStart(char) || char >= '0' && char <= '9'
+}
+
+// expressionNode A node of a compiled expression.
+type expressionNode interface {
+	evaluate(message interfaces.IPipeMessage, params interface{}) interface{}
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (self *literalNode) evaluate(message interfaces.IPipeMessage, params interface{}) interface{} {
+	return self.value
+}
+
+type pathNode struct {
+	segments []string
+}
+
+func (self *pathNode) evaluate(message interfaces.IPipeMessage, params interface{}) interface{} {
+	return normalize(self.value(message, params))
+}
+
+// value Get the value at the path, without normalizing it.
+func (self *pathNode) value(message interfaces.IPipeMessage, params interface{}) interface{} {
+	var value interface{}
+	segments := self.segments[1:]
+	switch self.segments[0] {
+	case "type":
+		return message.Type()
+	case "priority":
+		return message.Priority()
+	case "header":
+		value = message.Header()
+	case "body":
+		value = message.Body()
+	case "params":
+		value = params
+	case "metadata":
+		if len(segments) == 0 {
+			return nil
+		}
+		value = messages.Metadata(message, segments[0])
+		segments = segments[1:]
+	}
+	for _, segment := range segments {
+		value = selectSegment(value, segment)
+	}
+	return value
+}
+
+type comparisonNode struct {
+	operator string
+	left     expressionNode
+	right    expressionNode
+}
+
+func (self *comparisonNode) evaluate(message interfaces.IPipeMessage, params interface{}) interface{} {
+	left := self.left.evaluate(message, params)
+	right := self.right.evaluate(message, params)
+
+	switch self.operator {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	}
+
+	order := 0
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+		if l < r {
+			order = -1
+		} else if l > r {
+			order = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		order = strings.Compare(l, r)
+	default:
+		return false
+	}
+
+	switch self.operator {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+type logicalNode struct {
+	or    bool
+	left  expressionNode
+	right expressionNode
+}
+
+func (self *logicalNode) evaluate(message interfaces.IPipeMessage, params interface{}) interface{} {
+	left, _ := self.left.evaluate(message, params).(bool)
+	if left == self.or {
+		return left
+	}
+	right, _ := self.right.evaluate(message, params).(bool)
+	return right
+}
+
+type notNode struct {
+	operand expressionNode
+}
+
+func (self *notNode) evaluate(message interfaces.IPipeMessage, params interface{}) interface{} {
+	value, ok := self.operand.evaluate(message, params).(bool)
+	return ok && !value
+}
+
+// selectSegment Select a map key, exported struct field or slice index of a value, or nil.
+func selectSegment(value interface{}, segment string) interface{} {
+	current := reflect.ValueOf(value)
+	for current.Kind() == reflect.Ptr || current.Kind() == reflect.Interface {
+		if current.IsNil() {
+			return nil
+		}
+		current = current.Elem()
+	}
+
+	switch current.Kind() {
+	case reflect.Map:
+		if current.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		item := current.MapIndex(reflect.ValueOf(segment).Convert(current.Type().Key()))
+		if !item.IsValid() || !item.CanInterface() {
+			return nil
+		}
+		return item.Interface()
+	case reflect.Struct:
+		field, ok := current.Type().FieldByName(segment)
+		if !ok || field.PkgPath != "" {
+			return nil
+		}
+		item, err := current.FieldByIndexErr(field.Index) // promoted fields may sit behind a nil embedded pointer
+		if err != nil || !item.CanInterface() {
+			return nil
+		}
+		return item.Interface()
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 || index >= current.Len() {
+			return nil
+		}
+		return current.Index(index).Interface()
+	}
+	return nil
+}
+
+// normalize Convert numbers of any type to float64 and other comparable values to their basic type, anything else to nil.
+func normalize(value interface{}) interface{} {
+	current := reflect.ValueOf(value)
+	for current.Kind() == reflect.Ptr || current.Kind() == reflect.Interface {
+		if current.IsNil() {
+			return nil
+		}
+		current = current.Elem()
+	}
+
+	switch current.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(current.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(current.Uint())
+	case reflect.Float32, reflect.Float64:
+		return current.Float()
+	case reflect.String:
+		return current.String()
+	case reflect.Bool:
+		return current.Bool()
+	}
+	return nil
+}
@@ -27,6 +27,8 @@ type Filter struct {
 	Filter func(message interfaces.IPipeMessage, params interface{}) bool
 	Params interface{}
 	Mode   string
+
+	lastError error
 }
 
 /*
@@ -37,13 +39,21 @@ and write the result to the output pipe fitting if the filter
 operation is successful.
 
 The messages.SET_PARAMS message type tells the Filter
-that the message class is FilterControlMessage, which it
-casts the message to in order to retrieve the filter parameters
-object if the message is addressed to this filter.
+that the message implements IFilterControl, which it
+retrieves the filter parameters object from if the message
+is addressed to this filter.
 
 The messages.SET_FILTER message type tells the Filter
-that the message class is FilterControlMessage,
-which it casts the message to in order to retrieve the filter function.
+that the message implements IFilterControl, which it
+retrieves the filter function from. A nil filter function
+is rejected.
+
+The messages.SET_EXPRESSION message type tells the Filter
+that the message implements IFilterControl, which it
+retrieves the filter expression from, and compiles it into
+the filter function. If the expression does not compile, the
+filter function is left unchanged, the write fails, and the
+error is kept for LastError and the status of the Filter.
 
 The messages.BYPASS message type tells the Filter
 that it should go into Bypass mode operation, passing all normal
@@ -55,9 +65,14 @@ normal messages before writing out. This is the default
 mode of operation and so this message type need only be sent to
 cancel a previous BYPASS message.
 
+The messages.STATUS message type tells the Filter to report
+its mode and parameters to the StatusMessage query, then write
+it through to the output pipe fitting.
+
 The Filter only acts on the control message if it is targeted
 to this named filter instance. Otherwise, it writes through to the
-output.
+output. A message of a filter control type that does not
+implement IFilterControl is malformed, and rejected.
 
 - parameter message: IPipeMessage to write on the output
 
@@ -79,15 +94,31 @@ func (self *Filter) Write(message interfaces.IPipeMessage) bool {
 			success = self.Output.Write(message)
 		}
 	case messages.SET_PARAMS: // Accept parameters from control message
-		if self.IsTarget(message) {
-			self.Params = message.(*messages.FilterControlMessage).Params()
+		if control, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			self.Params = control.Params()
 		} else {
 			success = self.Output.Write(message)
 		}
 
 	case messages.SET_FILTER: // Accept filter function from control message
-		if self.IsTarget(message) {
-			self.Filter = message.(*messages.FilterControlMessage).Filter()
+		if control, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			if filter := control.Filter(); filter != nil {
+				self.Filter = filter
+			} else {
+				success = false
+			}
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.SET_EXPRESSION: // Compile filter function from control message
+		if control, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			success = self.SetExpression(control.Expression()) == nil
 		} else {
 			success = self.Output.Write(message)
 		}
@@ -95,11 +126,19 @@ func (self *Filter) Write(message interfaces.IPipeMessage) bool {
 	case messages.BYPASS:
 		fallthrough
 	case messages.FILTER:
-		if self.IsTarget(message) {
-			self.Mode = message.(*messages.FilterControlMessage).Type()
+		if _, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			self.Mode = message.Type()
 		} else {
 			success = self.Output.Write(message)
 		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Filter", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
 	default: // Write control messages for other fittings through
 		success = self.Output.Write(message)
 	}
@@ -109,10 +148,49 @@ func (self *Filter) Write(message interfaces.IPipeMessage) bool {
 
 // IsTarget Is the message directed at this filter instance?
 func (self *Filter) IsTarget(message interfaces.IPipeMessage) bool {
-	return message.(*messages.FilterControlMessage).Name() == self.Name
+	control, ok := message.(interfaces.IFilterControl)
+	return ok && control.Name() == self.Name
 }
 
-// ApplyFilter Filter the message.
+/*
+SetExpression Compile a filter expression and use it as the filter function.
+
+- parameter source: the text of the expression
+
+- returns: the *ExpressionError if the expression does not compile, leaving the filter function unchanged.
+*/
+func (self *Filter) SetExpression(source string) error {
+	expression, err := CompileExpression(source)
+	self.lastError = err
+	if err != nil {
+		return err
+	}
+	self.Filter = expression.Match
+	return nil
+}
+
+/*
+LastError Get the error of the last expression set, nil if it compiled.
+*/
+func (self *Filter) LastError() error {
+	return self.lastError
+}
+
+// ApplyFilter Filter the message, passing it if there is no filter function.
 func (self *Filter) ApplyFilter(message interfaces.IPipeMessage) bool {
+	if self.Filter == nil {
+		return true
+	}
 	return self.Filter(message, self.Params)
 }
+
+/*
+Status Get the mode and parameters of the filter, and the error of the last expression set if it did not compile.
+*/
+func (self *Filter) Status() map[string]interface{} {
+	status := map[string]interface{}{"mode": self.Mode, "params": self.Params}
+	if self.lastError != nil {
+		status["error"] = self.lastError.Error()
+	}
+	return status
+}
@@ -0,0 +1,352 @@
+//
+//  FilterChain.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"errors"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+)
+
+/*
+FilterChain Pipe Filter Chain.
+
+Filters normal messages through an ordered list of named
+predicates, each with its own parameters. A message is
+written to the output only if every predicate in the chain
+passes it, and the predicates are evaluated in order until
+one fails.
+
+Each predicate can be replaced, given new parameters, or
+bypassed and enabled again by a FilterControlMessage that
+names the chain and the predicate, without restructuring the
+pipeline. A predicate can also be given as an Expression by
+a messages.SET_EXPRESSION message. A messages.SET_FILTER or
+messages.SET_EXPRESSION message naming a predicate that is
+not in the chain adds it to the end. A messages.BYPASS
+or messages.FILTER message that names no predicate toggles
+the whole chain.
+
+Filtering is the default mode of operation of the chain and
+its predicates. Control messages for other fittings are
+written through to the output unchanged. A message of a
+filter control type that does not implement
+interfaces.IFilterControl is malformed, and rejected.
+*/
+type FilterChain struct {
+	Pipe
+	Name string
+	Mode string // messages.FILTER or messages.BYPASS
+
+	predicates []*chainPredicate
+	lastError  error
+	mutex      sync.Mutex
+}
+
+type chainPredicate struct {
+	name   string
+	filter Predicate
+	params interface{}
+	mode   string
+}
+
+/*
+Write Handle the incoming message.
+
+If message type is normal, filter the message through the
+chain (unless in BYPASS mode) and write it to the output pipe
+fitting if every predicate passes it.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean True if the message passed the chain and
+subsequent operations in the pipeline succeeds.
+*/
+func (self *FilterChain) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Filter normal messages
+		if self.ApplyFilter(message) {
+			success = self.Output.Write(message)
+		} else {
+			success = false
+		}
+	case messages.SET_PARAMS: // Accept predicate parameters from control message
+		if control, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			success = self.SetParams(control.Predicate(), control.Params())
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.SET_FILTER: // Accept predicate from control message
+		if control, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			success = self.SetFilter(control.Predicate(), control.Filter())
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.SET_EXPRESSION: // Compile predicate from control message
+		if control, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			success = self.SetExpression(control.Predicate(), control.Expression()) == nil
+		} else {
+			success = self.Output.Write(message)
+		}
+		// Toggle the chain or a predicate between Filter or Bypass operational modes
+	case messages.BYPASS:
+		fallthrough
+	case messages.FILTER:
+		if control, ok := message.(interfaces.IFilterControl); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			success = self.SetMode(control.Predicate(), control.Type())
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "FilterChain", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+// IsTarget Is the message directed at this filter chain instance?
+func (self *FilterChain) IsTarget(message interfaces.IPipeMessage) bool {
+	control, ok := message.(interfaces.IFilterControl)
+	return ok && control.Name() == self.Name
+}
+
+/*
+Add a predicate to the end of the chain, or replace the predicate of the same name.
+
+- parameter name: the name of the predicate
+
+- parameter filter: the predicate function
+
+- parameter params: the parameters passed to the predicate
+*/
+func (self *FilterChain) Add(name string, filter Predicate, params interface{}) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if predicate := self.find(name); predicate != nil {
+		predicate.filter = filter
+		predicate.params = params
+		return
+	}
+	self.predicates = append(self.predicates, &chainPredicate{name: name, filter: filter, params: params, mode: messages.FILTER})
+}
+
+/*
+Remove a predicate from the chain.
+
+- parameter name: the name of the predicate
+
+- returns: Bool false if there is no predicate of that name.
+*/
+func (self *FilterChain) Remove(name string) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	for index, predicate := range self.predicates {
+		if predicate.name == name {
+			self.predicates = append(self.predicates[:index], self.predicates[index+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Predicates Get the names of the predicates, in chain order.
+*/
+func (self *FilterChain) Predicates() []string {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	names := make([]string, len(self.predicates))
+	for index, predicate := range self.predicates {
+		names[index] = predicate.name
+	}
+	return names
+}
+
+/*
+SetFilter Replace the function of a predicate, or add it to the end of the chain.
+
+- parameter name: the name of the predicate
+
+- parameter filter: the predicate function
+
+- returns: Bool false if no predicate is named or the function is nil.
+*/
+func (self *FilterChain) SetFilter(name string, filter Predicate) bool {
+	if name == "" || filter == nil {
+		return false
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if predicate := self.find(name); predicate != nil {
+		predicate.filter = filter
+		return true
+	}
+	self.predicates = append(self.predicates, &chainPredicate{name: name, filter: filter, mode: messages.FILTER})
+	return true
+}
+
+/*
+SetExpression Compile an expression and use it as the function of a predicate, or add it to the end of the chain.
+
+- parameter name: the name of the predicate
+
+- parameter source: the text of the expression
+
+- returns: the *ExpressionError if the expression does not compile, leaving the predicate unchanged.
+*/
+func (self *FilterChain) SetExpression(name string, source string) error {
+	var expression *Expression
+	err := errors.New("filter chain: no predicate named")
+	if name != "" {
+		expression, err = CompileExpression(source)
+	}
+	self.mutex.Lock()
+	self.lastError = err
+	self.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	self.SetFilter(name, expression.Match)
+	return nil
+}
+
+/*
+LastError Get the error of the last expression set, nil if it compiled.
+*/
+func (self *FilterChain) LastError() error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.lastError
+}
+
+/*
+SetParams Set the parameters of a predicate.
+
+- parameter name: the name of the predicate
+
+- parameter params: the parameters passed to the predicate
+
+- returns: Bool false if there is no predicate of that name.
+*/
+func (self *FilterChain) SetParams(name string, params interface{}) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	predicate := self.find(name)
+	if predicate == nil {
+		return false
+	}
+	predicate.params = params
+	return true
+}
+
+/*
+SetMode Toggle a predicate, or the whole chain, between filtering and bypass.
+
+- parameter name: the name of the predicate, or empty for the whole chain
+
+- parameter mode: messages.FILTER or messages.BYPASS
+
+- returns: Bool false if there is no predicate of that name.
+*/
+func (self *FilterChain) SetMode(name string, mode string) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if name == "" {
+		self.Mode = mode
+		return true
+	}
+	predicate := self.find(name)
+	if predicate == nil {
+		return false
+	}
+	predicate.mode = mode
+	return true
+}
+
+/*
+ApplyFilter Filter the message through the chain.
+
+- returns: Bool true if every enabled predicate passes the message, or the chain is bypassed.
+*/
+func (self *FilterChain) ApplyFilter(message interfaces.IPipeMessage) bool {
+	self.mutex.Lock()
+	if self.Mode == messages.BYPASS {
+		self.mutex.Unlock()
+		return true
+	}
+	predicates := make([]chainPredicate, len(self.predicates))
+	for index, predicate := range self.predicates {
+		predicates[index] = *predicate
+	}
+	self.mutex.Unlock()
+
+	for _, predicate := range predicates {
+		if predicate.mode != messages.BYPASS && predicate.filter != nil && !predicate.filter(message, predicate.params) {
+			return false
+		}
+	}
+	return true
+}
+
+// find Get the predicate of a name, or nil. Caller holds the mutex.
+func (self *FilterChain) find(name string) *chainPredicate {
+	for _, predicate := range self.predicates {
+		if predicate.name == name {
+			return predicate
+		}
+	}
+	return nil
+}
+
+/*
+Status Get the mode of the chain, the names of its predicates and of the bypassed ones, and the error of the last expression set if it did not compile.
+*/
+func (self *FilterChain) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	predicates := make([]string, 0, len(self.predicates))
+	bypassed := make([]string, 0)
+	for _, predicate := range self.predicates {
+		predicates = append(predicates, predicate.name)
+		if predicate.mode == messages.BYPASS {
+			bypassed = append(bypassed, predicate.name)
+		}
+	}
+	status := map[string]interface{}{"mode": self.Mode, "predicates": predicates, "bypassed": bypassed}
+	if self.lastError != nil {
+		status["error"] = self.lastError.Error()
+	}
+	return status
+}
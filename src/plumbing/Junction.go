@@ -10,12 +10,19 @@ package plumbing
 
 import (
 	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"path"
+	"sort"
 	"sync"
 )
 
+/*
+PipeDirection The direction of a pipe registered with a Junction, INPUT or OUTPUT.
+*/
+type PipeDirection string
+
 const (
-	INPUT  = "input"
-	OUTPUT = "output"
+	INPUT  PipeDirection = "input"  // A pipe the module receives messages on
+	OUTPUT PipeDirection = "output" // A pipe the module sends messages on
 )
 
 /*
@@ -28,58 +35,118 @@ declared as being an INPUT pipe or an OUTPUT pipe.
 
 You can retrieve or remove a registered Pipe by name,
 check to see if a Pipe with a given name exists, or if
-it exists AND is an INPUT or an OUTPUT Pipe.
-
-You can send an IPipeMessage on a named INPUT Pipe
-or add a PipeListener to registered INPUT Pipe.
+it exists AND is an INPUT or an OUTPUT Pipe, and list the
+names of the registered pipes.
+
+You can send an IPipeMessage on a named OUTPUT Pipe, on
+every OUTPUT Pipe, on those whose names match, or on those
+tagged with a group label when they were registered, or add
+a PipeListener to registered INPUT Pipe.
+
+A JunctionPolicy set on the Junction decides which messages
+may be sent on its OUTPUT pipes, and which may be delivered
+from its INPUT pipes to their PipeListeners, and an AuditLog
+set on it records the messages denied. Messages sent with
+the Send methods of the Junction have no sender identity;
+hand each module or plugin a JunctionSender from Sender
+instead, so that the policy knows who sent a message.
+While there is a policy, OUTPUT pipes handed out by the
+Junction are wrapped so that writing to them directly is
+subject to the policy too. The policy cannot govern code that
+kept its own reference to a pipe it registered.
+
+Create a Junction with NewJunction. The zero value is also
+an empty Junction ready to use. A Junction is safe to use
+from multiple goroutines.
+
+A Junction literal given pipes in the deprecated PipesMap and
+PipeTypesMap registers them, in name order, when it is first
+used, and those maps are kept up to date with the pipes
+registered and removed afterwards, under PipesMapMutex.
 */
 type Junction struct {
-	inputPipes    []string
-	outputPipes   []string
-	PipesMap      map[string]interfaces.IPipeFitting
+	// Deprecated: Use NewJunction, RegisterPipe and Pipes.
+	PipesMap map[string]interfaces.IPipeFitting
+	// Deprecated: Use Direction.
+	PipeTypesMap map[string]string
+	// Deprecated: Guards PipesMap and PipeTypesMap only.
 	PipesMapMutex sync.RWMutex
-	PipeTypesMap  map[string]string
+
+	pipes       map[string]interfaces.IPipeFitting
+	directions  map[string]PipeDirection
+	groups      map[string][]string
+	inputPipes  []string
+	outputPipes []string
+	policy      JunctionPolicy
+	audit       *AuditLog
+	folded      sync.Once
+	mutex       sync.RWMutex
+}
+
+/*
+NewJunction Constructor
+*/
+func NewJunction() *Junction {
+	return &Junction{pipes: map[string]interfaces.IPipeFitting{}, directions: map[string]PipeDirection{}, groups: map[string][]string{}}
 }
 
 /*
 RegisterPipe  Register a pipe with the junction.
 
-Pipes are registered by unique name and type,
-which must be either Junction.INPUT
-or Junction.OUTPUT.
+Pipes are registered by unique name and direction,
+which must be either INPUT or OUTPUT.
 
 NOTE: You cannot have an INPUT pipe and an OUTPUT
 pipe registered with the same name. All pipe names
-must be unique regardless of type.
+must be unique regardless of direction.
 
 - parameter name: name of the Pipe Fitting
 
-- parameter type: input or output
+- parameter direction: INPUT or OUTPUT
 
 - parameter pipe: instance of the IPipeFitting
 
-- returns: Bool true if successfully registered. false if another pipe exists by that name.
+- parameter groups: labels of the groups the pipe belongs to, for SendToGroup
+
+- returns: Bool true if successfully registered. false if another pipe exists by that name, the direction is not valid or the pipe is nil.
 */
-func (self *Junction) RegisterPipe(name string, _type string, pipe interfaces.IPipeFitting) bool {
-	self.PipesMapMutex.Lock()
-	defer self.PipesMapMutex.Unlock()
+func (self *Junction) RegisterPipe(name string, direction PipeDirection, pipe interfaces.IPipeFitting, groups ...string) bool {
+	self.lock()
+	defer self.mutex.Unlock()
 
-	success := true
-	if self.PipesMap[name] == nil {
-		self.PipesMap[name] = pipe
-		self.PipeTypesMap[name] = _type
-		switch _type {
-		case INPUT:
-			self.inputPipes = append(self.inputPipes, name)
-		case OUTPUT:
-			self.outputPipes = append(self.outputPipes, name)
-		default:
-			success = false
-		}
-	} else {
-		success = false
+	if !self.register(name, direction, pipe, groups) {
+		return false
 	}
-	return success
+	self.mirror(name, direction, pipe)
+	return true
+}
+
+// register Add a pipe to the registry. Caller holds the mutex.
+func (self *Junction) register(name string, direction PipeDirection, pipe interfaces.IPipeFitting, groups []string) bool {
+	if pipe == nil || self.pipes[name] != nil {
+		return false
+	}
+	switch direction {
+	case INPUT:
+		self.inputPipes = append(self.inputPipes, name)
+	case OUTPUT:
+		self.outputPipes = append(self.outputPipes, name)
+	default:
+		return false
+	}
+	if self.pipes == nil {
+		self.pipes = map[string]interfaces.IPipeFitting{}
+		self.directions = map[string]PipeDirection{}
+	}
+	if self.groups == nil {
+		self.groups = map[string][]string{}
+	}
+	self.pipes[name] = pipe
+	self.directions[name] = direction
+	if len(groups) > 0 {
+		self.groups[name] = append([]string{}, groups...)
+	}
+	return true
 }
 
 /*
@@ -90,10 +157,10 @@ HasPipe Does this junction have a pipe by this name?
 - returns: Bool whether as pipe is registered with that name.
 */
 func (self *Junction) HasPipe(name string) bool {
-	self.PipesMapMutex.RLock()
-	defer self.PipesMapMutex.RUnlock()
+	self.rlock()
+	defer self.mutex.RUnlock()
 
-	return self.PipesMap[name] != nil
+	return self.pipes[name] != nil
 }
 
 /*
@@ -104,10 +171,7 @@ HasInputPipe Does this junction have an INPUT pipe by this name?
 - returns: Bool whether an INPUT pipe is registered with that name.
 */
 func (self *Junction) HasInputPipe(name string) bool {
-	self.PipesMapMutex.RLock()
-	defer self.PipesMapMutex.RUnlock()
-
-	return self.HasPipe(name) && self.PipeTypesMap[name] == INPUT
+	return self.Direction(name) == INPUT
 }
 
 /*
@@ -118,10 +182,21 @@ HasOutputPipe Does this junction have an OUTPUT pipe by this name?
 - returns: Bool whether an OUTPUT pipe is registered with that name.
 */
 func (self *Junction) HasOutputPipe(name string) bool {
-	self.PipesMapMutex.RLock()
-	defer self.PipesMapMutex.RUnlock()
+	return self.Direction(name) == OUTPUT
+}
+
+/*
+Direction Get the direction of the pipe with this name.
+
+- parameter name: the pipe to check for
+
+- returns: INPUT or OUTPUT, or an empty PipeDirection if no pipe is registered with that name.
+*/
+func (self *Junction) Direction(name string) PipeDirection {
+	self.rlock()
+	defer self.mutex.RUnlock()
 
-	return self.HasPipe(name) && self.PipeTypesMap[name] == OUTPUT
+	return self.directions[name]
 }
 
 /*
@@ -129,44 +204,132 @@ RemovePipe Remove the pipe with this name if it is registered.
 
 NOTE: You cannot have an INPUT pipe and an OUTPUT
 pipe registered with the same name. All pipe names
-must be unique regardless of type.
+must be unique regardless of direction.
 
 - parameter name: the pipe to remove
 */
 func (self *Junction) RemovePipe(name string) {
-	self.PipesMapMutex.Lock()
-	defer self.PipesMapMutex.Unlock()
-
-	if self.PipesMap[name] != nil {
-		var pipesList []string
-		switch self.PipeTypesMap[name] {
-		case INPUT:
-			pipesList = self.inputPipes
-		case OUTPUT:
-			pipesList = self.outputPipes
-		}
-		for index, pipeName := range pipesList {
-			if pipeName == name {
-				pipesList = append(pipesList[0:index], pipesList[index+1:]...)
-			}
-		}
-		delete(self.PipesMap, name)
-		delete(self.PipeTypesMap, name)
+	self.lock()
+	defer self.mutex.Unlock()
+
+	switch self.directions[name] {
+	case INPUT:
+		self.inputPipes = removeName(self.inputPipes, name)
+	case OUTPUT:
+		self.outputPipes = removeName(self.outputPipes, name)
 	}
+	delete(self.pipes, name)
+	delete(self.directions, name)
+	delete(self.groups, name)
+	self.mirror(name, "", nil)
+}
+
+/*
+Groups Get the labels of the groups the pipe with this name belongs to.
+
+- parameter name: the pipe to check for
+
+- returns: the labels given when the pipe was registered.
+*/
+func (self *Junction) Groups(name string) []string {
+	self.rlock()
+	defer self.mutex.RUnlock()
+
+	return append([]string{}, self.groups[name]...)
 }
 
 /*
 RetrievePipe Retrieve the named pipe.
 
+If the junction has a policy, an OUTPUT pipe is returned
+wrapped so that messages written to it pass the policy,
+as messages sent with SendMessage do.
+
 - parameter name: the pipe to retrieve
 
 - returns: IPipeFitting the pipe registered by the given name if it exists
 */
 func (self *Junction) RetrievePipe(name string) interfaces.IPipeFitting {
-	self.PipesMapMutex.RLock()
-	defer self.PipesMapMutex.RUnlock()
+	self.rlock()
+	defer self.mutex.RUnlock()
 
-	return self.PipesMap[name]
+	return self.guard(name)
+}
+
+/*
+InputPipeNames Get the names of the INPUT pipes.
+
+- returns: the names, in the order the pipes were registered.
+*/
+func (self *Junction) InputPipeNames() []string {
+	self.rlock()
+	defer self.mutex.RUnlock()
+
+	return append([]string{}, self.inputPipes...)
+}
+
+/*
+OutputPipeNames Get the names of the OUTPUT pipes.
+
+- returns: the names, in the order the pipes were registered.
+*/
+func (self *Junction) OutputPipeNames() []string {
+	self.rlock()
+	defer self.mutex.RUnlock()
+
+	return append([]string{}, self.outputPipes...)
+}
+
+/*
+Pipes Get all registered pipes.
+
+If the junction has a policy, OUTPUT pipes are wrapped as
+by RetrievePipe.
+
+- returns: a copy of the registered pipes by name, safe to iterate while the junction changes.
+*/
+func (self *Junction) Pipes() map[string]interfaces.IPipeFitting {
+	self.rlock()
+	defer self.mutex.RUnlock()
+
+	pipes := make(map[string]interfaces.IPipeFitting, len(self.pipes))
+	for name := range self.pipes {
+		pipes[name] = self.guard(name)
+	}
+	return pipes
+}
+
+// guard Get the named pipe, wrapped in the policy check if it is an OUTPUT pipe and there is a policy. Caller holds the mutex.
+func (self *Junction) guard(name string) interfaces.IPipeFitting {
+	pipe := self.pipes[name]
+	if pipe == nil || self.policy == nil || self.directions[name] != OUTPUT {
+		return pipe
+	}
+	return &policyPipe{junction: self, name: name, pipe: pipe}
+}
+
+/*
+SetPolicy Set the policy deciding which messages may be sent and delivered.
+
+- parameter policy: the JunctionPolicy, nil to allow all messages
+*/
+func (self *Junction) SetPolicy(policy JunctionPolicy) {
+	self.lock()
+	defer self.mutex.Unlock()
+
+	self.policy = policy
+}
+
+/*
+SetAuditLog Set the log of the messages the policy denies.
+
+- parameter audit: the *AuditLog, nil for none
+*/
+func (self *Junction) SetAuditLog(audit *AuditLog) {
+	self.lock()
+	defer self.mutex.Unlock()
+
+	self.audit = audit
 }
 
 /*
@@ -181,12 +344,13 @@ NOTE: there can only be one PipeListener per pipe, and the listener function mus
 - parameter listener: the function on the context to call
 */
 func (self *Junction) AddPipeListener(inputPipeName string, context interface{}, listener func(message interfaces.IPipeMessage)) bool {
+	self.lock()
+	defer self.mutex.Unlock()
+
 	success := false
-	if self.HasInputPipe(inputPipeName) {
-		self.PipesMapMutex.Lock()
-		pipe := self.PipesMap[inputPipeName]
-		success = pipe.Connect(&PipeListener{Context: context, Listener: listener})
-		self.PipesMapMutex.Unlock()
+	if self.directions[inputPipeName] == INPUT {
+		listener := &PipeListener{Context: context, Listener: listener}
+		success = self.pipes[inputPipeName].Connect(&policyListener{junction: self, name: inputPipeName, listener: listener})
 	}
 	return success
 }
@@ -194,18 +358,299 @@ func (self *Junction) AddPipeListener(inputPipeName string, context interface{},
 /*
 SendMessage Send a message on an OUTPUT pipe.
 
+The message is written outside the lock of the junction, so
+the pipes it reaches may change the junction.
+
 - parameter outputPipeName: the OUTPUT pipe to send the message on
 
 - parameter message: the IPipeMessage to send
 */
 func (self *Junction) SendMessage(outputPipeName string, message interfaces.IPipeMessage) bool {
-	self.PipesMapMutex.RLock()
-	defer self.PipesMapMutex.RUnlock()
+	return self.sendMessage("", outputPipeName, message)
+}
+
+/*
+SendToAll Send a message on every OUTPUT pipe.
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each pipe.
+*/
+func (self *Junction) SendToAll(message interfaces.IPipeMessage) SendReport {
+	return self.sendToMatching("", func(name string) bool { return true }, message)
+}
+
+/*
+SendToMatching Send a message on the OUTPUT pipes whose names match a glob pattern.
+
+The pattern has the syntax of path.Match, e.g. "worker/*".
+
+- parameter pattern: the glob pattern
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each matching pipe, or path.ErrBadPattern if the pattern is malformed, in which case nothing is sent.
+*/
+func (self *Junction) SendToMatching(pattern string, message interfaces.IPipeMessage) (SendReport, error) {
+	return self.sendToPattern("", pattern, message)
+}
+
+/*
+SendToMatchingFunc Send a message on the OUTPUT pipes whose names a predicate selects.
+
+- parameter match: returns true for the names of the pipes to send on
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each selected pipe.
+*/
+func (self *Junction) SendToMatchingFunc(match func(name string) bool, message interfaces.IPipeMessage) SendReport {
+	return self.sendToMatching("", match, message)
+}
+
+/*
+SendToGroup Send a message on the OUTPUT pipes in a group.
+
+- parameter group: the label the pipes were registered with
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each pipe in the group.
+*/
+func (self *Junction) SendToGroup(group string, message interfaces.IPipeMessage) SendReport {
+	return self.sendToGroup("", group, message)
+}
+
+/*
+Sender Get a handle that sends messages on this junction as a sender identity.
+
+- parameter identity: the identity the JunctionPolicy sees as the sender of the messages
+
+- returns: *JunctionSender the handle
+*/
+func (self *Junction) Sender(identity string) *JunctionSender {
+	return &JunctionSender{junction: self, identity: identity}
+}
+
+// sendMessage Send a message on an OUTPUT pipe as the sender.
+func (self *Junction) sendMessage(sender string, outputPipeName string, message interfaces.IPipeMessage) bool {
+	self.rlock()
+	pipe, direction := self.pipes[outputPipeName], self.directions[outputPipeName]
+	self.mutex.RUnlock()
 
 	success := false
-	if self.HasOutputPipe(outputPipeName) {
-		pipe := self.PipesMap[outputPipeName]
+	if direction == OUTPUT && self.allow(outputPipeName, OUTPUT, message, sender) {
 		success = pipe.Write(message)
 	}
 	return success
 }
+
+// sendToPattern Send a message as the sender on the OUTPUT pipes whose names match a glob pattern.
+func (self *Junction) sendToPattern(sender string, pattern string, message interfaces.IPipeMessage) (SendReport, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return SendReport{}, err
+	}
+	return self.sendToMatching(sender, func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}, message), nil
+}
+
+// sendToMatching Send a message as the sender on the OUTPUT pipes whose names a predicate selects.
+func (self *Junction) sendToMatching(sender string, match func(name string) bool, message interfaces.IPipeMessage) SendReport {
+	report := SendReport{}
+	for _, output := range self.outputs() {
+		if match(output.name) {
+			report[output.name] = self.allow(output.name, OUTPUT, message, sender) && output.pipe.Write(message)
+		}
+	}
+	return report
+}
+
+// sendToGroup Send a message as the sender on the OUTPUT pipes in a group.
+func (self *Junction) sendToGroup(sender string, group string, message interfaces.IPipeMessage) SendReport {
+	report := SendReport{}
+	for _, output := range self.outputs() {
+		for _, each := range output.groups {
+			if each == group {
+				report[output.name] = self.allow(output.name, OUTPUT, message, sender) && output.pipe.Write(message)
+				break
+			}
+		}
+	}
+	return report
+}
+
+// allow Ask the policy whether a message of the sender may pass a pipe, recording a denial in the audit log.
+func (self *Junction) allow(name string, direction PipeDirection, message interfaces.IPipeMessage, sender string) bool {
+	self.rlock()
+	policy, audit := self.policy, self.audit
+	self.mutex.RUnlock()
+
+	if policy == nil {
+		return true
+	}
+	request := NewPolicyRequest(name, direction, message, sender)
+	allowed, reason := policy.Allow(request)
+	if !allowed && audit != nil {
+		audit.Record(request, reason)
+	}
+	return allowed
+}
+
+// policyListener The PipeListener of an INPUT pipe, receiving only the messages the policy of the junction allows.
+type policyListener struct {
+	junction *Junction
+	name     string
+	listener *PipeListener
+}
+
+func (self *policyListener) Connect(output interfaces.IPipeFitting) bool {
+	return false
+}
+
+func (self *policyListener) Disconnect() interfaces.IPipeFitting {
+	return nil
+}
+
+func (self *policyListener) Write(message interfaces.IPipeMessage) bool {
+	return self.junction.allow(self.name, INPUT, message, "") && self.listener.Write(message)
+}
+
+// policyPipe An OUTPUT pipe handed out by a junction, writing only the messages the policy of the junction allows.
+type policyPipe struct {
+	junction *Junction
+	name     string
+	pipe     interfaces.IPipeFitting
+}
+
+func (self *policyPipe) Connect(output interfaces.IPipeFitting) bool {
+	return self.pipe.Connect(output)
+}
+
+func (self *policyPipe) Disconnect() interfaces.IPipeFitting {
+	return self.pipe.Disconnect()
+}
+
+func (self *policyPipe) Write(message interfaces.IPipeMessage) bool {
+	return self.junction.allow(self.name, OUTPUT, message, "") && self.pipe.Write(message)
+}
+
+// lock Lock the registry for writing, once it has the pipes of the deprecated fields.
+func (self *Junction) lock() {
+	self.folded.Do(self.fold)
+	self.mutex.Lock()
+}
+
+// rlock Lock the registry for reading, once it has the pipes of the deprecated fields.
+func (self *Junction) rlock() {
+	self.folded.Do(self.fold)
+	self.mutex.RLock()
+}
+
+// fold Register the pipes a Junction literal was given in the deprecated PipesMap, in name order.
+func (self *Junction) fold() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.PipesMapMutex.RLock()
+	defer self.PipesMapMutex.RUnlock()
+
+	names := make([]string, 0, len(self.PipesMap))
+	for name := range self.PipesMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		self.register(name, PipeDirection(self.PipeTypesMap[name]), self.PipesMap[name], nil)
+	}
+}
+
+// mirror Keep the deprecated PipesMap and PipeTypesMap of a Junction literal up to date, removing the pipe if it is nil. Caller holds the mutex.
+func (self *Junction) mirror(name string, direction PipeDirection, pipe interfaces.IPipeFitting) {
+	if self.PipesMap == nil {
+		return
+	}
+	self.PipesMapMutex.Lock()
+	defer self.PipesMapMutex.Unlock()
+
+	if pipe == nil {
+		delete(self.PipesMap, name)
+		delete(self.PipeTypesMap, name)
+		return
+	}
+	self.PipesMap[name] = pipe
+	if self.PipeTypesMap != nil {
+		self.PipeTypesMap[name] = string(direction)
+	}
+}
+
+// junctionOutput An OUTPUT pipe registered with a junction.
+type junctionOutput struct {
+	name   string
+	pipe   interfaces.IPipeFitting
+	groups []string
+}
+
+// outputs Get the OUTPUT pipes in order registered, so messages can be written to them outside the lock.
+func (self *Junction) outputs() []junctionOutput {
+	self.rlock()
+	defer self.mutex.RUnlock()
+
+	outputs := make([]junctionOutput, len(self.outputPipes))
+	for index, name := range self.outputPipes {
+		outputs[index] = junctionOutput{name: name, pipe: self.pipes[name], groups: self.groups[name]}
+	}
+	return outputs
+}
+
+/*
+SendReport The result of sending a message on several pipes.
+
+Maps the name of each pipe the message was sent on to
+whether the pipe accepted it.
+*/
+type SendReport map[string]bool
+
+/*
+Sent Get the names of the pipes that accepted the message, in order.
+*/
+func (self SendReport) Sent() []string {
+	return self.names(true)
+}
+
+/*
+Failed Get the names of the pipes that did not accept the message, in order.
+*/
+func (self SendReport) Failed() []string {
+	return self.names(false)
+}
+
+/*
+OK Did every pipe accept the message?
+*/
+func (self SendReport) OK() bool {
+	return len(self.Failed()) == 0
+}
+
+// names Get the sorted names of the pipes with the given result.
+func (self SendReport) names(sent bool) []string {
+	names := []string{}
+	for name, result := range self {
+		if result == sent {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// removeName Get a list of names without the given name.
+func removeName(names []string, name string) []string {
+	kept := make([]string, 0, len(names))
+	for _, each := range names {
+		if each != name {
+			kept = append(kept, each)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,281 @@
+//
+//  JunctionPolicy.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	POLICY_ALLOW = "allow" // Let the message through
+	POLICY_DENY  = "deny"  // Drop the message
+)
+
+/*
+JunctionPolicy Access control for a Junction.
+
+Consulted by the Junction for every message sent on an
+OUTPUT pipe, and for every message delivered from an INPUT
+pipe to its PipeListener. Denied messages are dropped and
+recorded in the audit log of the junction, if it has one.
+*/
+type JunctionPolicy interface {
+	Allow(request PolicyRequest) (allowed bool, reason string) // Decide on a message, giving the reason for a denial
+}
+
+/*
+PolicyRequest A message a Junction asks its JunctionPolicy about.
+*/
+type PolicyRequest struct {
+	Pipe      string                  // Name of the pipe the message is sent on or delivered from
+	Direction PipeDirection           // OUTPUT when sending, INPUT when delivering
+	Message   interfaces.IPipeMessage // The message
+	Sender    string                  // Identity of the JunctionSender the message was sent through, empty if none
+}
+
+/*
+NewPolicyRequest Constructor
+
+The sender is the identity the Junction bound to the caller,
+never the metadata of the message, which the sender could set
+to anything. Messages delivered from INPUT pipes have no
+sender, since they come from outside the junction; rules on
+them go by pipe and type.
+*/
+func NewPolicyRequest(pipe string, direction PipeDirection, message interfaces.IPipeMessage, sender string) PolicyRequest {
+	return PolicyRequest{Pipe: pipe, Direction: direction, Message: message, Sender: sender}
+}
+
+/*
+PolicyRule A rule of a RulePolicy.
+
+A rule matches a request if every list it has matches it.
+Pipes, Types and Senders are lists of patterns with the syntax
+of path.Match, e.g. "plugin/*". In patterns of pipe names, *
+and ? do not match /, as in path.Match. Message types are URLs
+and senders may be paths, so in their patterns * and ? match
+/ too, e.g. "*" matches any type and "http://example.com/*"
+any type under that URL. An empty list matches anything.
+*/
+type PolicyRule struct {
+	Effect    string        `json:"effect"`              // POLICY_ALLOW or POLICY_DENY
+	Direction PipeDirection `json:"direction,omitempty"` // INPUT or OUTPUT, empty for both
+	Pipes     []string      `json:"pipes,omitempty"`     // Patterns of pipe names
+	Types     []string      `json:"types,omitempty"`     // Patterns of message types
+	Senders   []string      `json:"senders,omitempty"`   // Patterns of sender identities
+}
+
+/*
+RulePolicy Rule-based JunctionPolicy.
+
+The first of the Rules that matches a request decides it.
+If no rule matches, the Default effect decides it, which
+denies unless it is POLICY_ALLOW.
+
+A RulePolicy can be loaded from a JSON file, e.g.
+
+	{
+	  "default": "deny",
+	  "rules": [
+	    {"effect": "deny", "senders": ["untrusted/*"]},
+	    {"effect": "allow", "direction": "output", "pipes": ["plugin/*"]},
+	    {"effect": "allow", "direction": "input", "types": ["http://example.com/pipes/*"]}
+	  ]
+	}
+*/
+type RulePolicy struct {
+	Rules   []PolicyRule `json:"rules"`
+	Default string       `json:"default"` // POLICY_ALLOW or POLICY_DENY
+}
+
+/*
+LoadRulePolicy Read a RulePolicy from a JSON file.
+
+- parameter filename: the file to read
+
+- returns: the *RulePolicy, or an error if the file cannot be read or the policy is not valid.
+*/
+func LoadRulePolicy(filename string) (*RulePolicy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRulePolicy(data)
+}
+
+/*
+ParseRulePolicy Parse a RulePolicy from JSON.
+
+- parameter data: the JSON
+
+- returns: the *RulePolicy, or an error if the JSON is malformed or the policy is not valid.
+*/
+func ParseRulePolicy(data []byte) (*RulePolicy, error) {
+	policy := &RulePolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+/*
+Validate Check the effects, directions and patterns of the policy.
+
+- returns: an error describing the first invalid rule, or nil.
+*/
+func (self *RulePolicy) Validate() error {
+	if self.Default != "" && self.Default != POLICY_ALLOW && self.Default != POLICY_DENY {
+		return fmt.Errorf("policy: invalid default effect %q", self.Default)
+	}
+	for index, rule := range self.Rules {
+		if rule.Effect != POLICY_ALLOW && rule.Effect != POLICY_DENY {
+			return fmt.Errorf("policy: rule %d: invalid effect %q", index, rule.Effect)
+		}
+		if rule.Direction != "" && rule.Direction != INPUT && rule.Direction != OUTPUT {
+			return fmt.Errorf("policy: rule %d: invalid direction %q", index, rule.Direction)
+		}
+		for _, patterns := range [][]string{rule.Pipes, rule.Types, rule.Senders} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("policy: rule %d: invalid pattern %q", index, pattern)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Allow Decide on a request by the first rule that matches it.
+
+- parameter request: the PolicyRequest
+
+- returns: Bool true if the message is allowed, and the reason if not.
+*/
+func (self *RulePolicy) Allow(request PolicyRequest) (bool, string) {
+	for index, rule := range self.Rules {
+		if rule.matches(request) {
+			if rule.Effect == POLICY_ALLOW {
+				return true, ""
+			}
+			return false, fmt.Sprintf("denied by rule %d", index)
+		}
+	}
+	if self.Default == POLICY_ALLOW {
+		return true, ""
+	}
+	return false, "no rule allows it"
+}
+
+// matches Does the rule match the request?
+func (self *PolicyRule) matches(request PolicyRequest) bool {
+	return (self.Direction == "" || self.Direction == request.Direction) &&
+		matchesAny(self.Pipes, request.Pipe, path.Match) &&
+		matchesAny(self.Types, request.Message.Type(), matchGlob) &&
+		matchesAny(self.Senders, request.Sender, matchGlob)
+}
+
+// matchesAny Does any of the patterns match the name? True if there are no patterns.
+func matchesAny(patterns []string, name string, match func(pattern string, name string) (bool, error)) bool {
+	for _, pattern := range patterns {
+		if matched, _ := match(pattern, name); matched {
+			return true
+		}
+	}
+	return len(patterns) == 0
+}
+
+// globs The regular expressions of the patterns matched by matchGlob.
+var globs sync.Map
+
+// matchGlob Does the pattern match the name? As path.Match, except that * and ? match / too.
+func matchGlob(pattern string, name string) (bool, error) {
+	compiled, ok := globs.Load(pattern)
+	if !ok {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return false, err
+		}
+		expression, err := regexp.Compile(globExpression(pattern))
+		if err != nil {
+			return false, err
+		}
+		compiled, _ = globs.LoadOrStore(pattern, expression)
+	}
+	return compiled.(*regexp.Regexp).MatchString(name), nil
+}
+
+// globExpression Translate a valid path.Match pattern to a regular expression in which * and ? match / too.
+func globExpression(pattern string) string {
+	var expression strings.Builder
+	expression.WriteString(`(?s)^`)
+	for index := 0; index < len(pattern); index++ {
+		switch pattern[index] {
+		case '*':
+			expression.WriteString(`.*`)
+		case '?':
+			expression.WriteString(`.`)
+		case '\\':
+			index++
+			expression.WriteString(regexp.QuoteMeta(pattern[index : index+1]))
+		case '[':
+			index++
+			negated := pattern[index] == '^'
+			if negated {
+				index++
+			}
+			var class strings.Builder
+			for pattern[index] != ']' {
+				lo, size := classRune(pattern, index)
+				index += size
+				hi := lo
+				if pattern[index] == '-' {
+					hi, size = classRune(pattern, index+1)
+					index += 1 + size
+				}
+				if lo <= hi { // an inverted range matches nothing
+					fmt.Fprintf(&class, `\x{%x}-\x{%x}`, lo, hi)
+				}
+			}
+			switch {
+			case class.Len() == 0 && negated:
+				expression.WriteString(`.`)
+			case class.Len() == 0:
+				expression.WriteString(`[^\x00-\x{10ffff}]`)
+			case negated:
+				expression.WriteString(`[^` + class.String() + `]`)
+			default:
+				expression.WriteString(`[` + class.String() + `]`)
+			}
+		default:
+			expression.WriteString(regexp.QuoteMeta(pattern[index : index+1]))
+		}
+	}
+	expression.WriteString(`$`)
+	return expression.String()
+}
+
+// classRune Decode the possibly escaped character of a character class at the index, returning it and its length.
+func classRune(pattern string, index int) (rune, int) {
+	if pattern[index] == '\\' {
+		r, size := utf8.DecodeRuneInString(pattern[index+1:])
+		return r, size + 1
+	}
+	return utf8.DecodeRuneInString(pattern[index:])
+}
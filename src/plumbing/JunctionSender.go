@@ -0,0 +1,93 @@
+//
+//  JunctionSender.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import "github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+
+/*
+JunctionSender Sends messages on a Junction as a sender identity.
+
+Get one from Junction.Sender and hand it to the module or
+plugin the identity belongs to, instead of the Junction
+itself. The JunctionPolicy of the junction sees the identity
+of the handle as the sender of every message sent through it,
+whatever the metadata of the message claims.
+*/
+type JunctionSender struct {
+	junction *Junction
+	identity string
+}
+
+/*
+Identity Get the identity the messages are sent as.
+*/
+func (self *JunctionSender) Identity() string {
+	return self.identity
+}
+
+/*
+SendMessage Send a message on an OUTPUT pipe.
+
+- parameter outputPipeName: the OUTPUT pipe to send the message on
+
+- parameter message: the IPipeMessage to send
+*/
+func (self *JunctionSender) SendMessage(outputPipeName string, message interfaces.IPipeMessage) bool {
+	return self.junction.sendMessage(self.identity, outputPipeName, message)
+}
+
+/*
+SendToAll Send a message on every OUTPUT pipe.
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each pipe.
+*/
+func (self *JunctionSender) SendToAll(message interfaces.IPipeMessage) SendReport {
+	return self.junction.sendToMatching(self.identity, func(name string) bool { return true }, message)
+}
+
+/*
+SendToMatching Send a message on the OUTPUT pipes whose names match a glob pattern.
+
+- parameter pattern: the glob pattern, as in Junction.SendToMatching
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each matching pipe, or path.ErrBadPattern if the pattern is malformed, in which case nothing is sent.
+*/
+func (self *JunctionSender) SendToMatching(pattern string, message interfaces.IPipeMessage) (SendReport, error) {
+	return self.junction.sendToPattern(self.identity, pattern, message)
+}
+
+/*
+SendToMatchingFunc Send a message on the OUTPUT pipes whose names a predicate selects.
+
+- parameter match: returns true for the names of the pipes to send on
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each selected pipe.
+*/
+func (self *JunctionSender) SendToMatchingFunc(match func(name string) bool, message interfaces.IPipeMessage) SendReport {
+	return self.junction.sendToMatching(self.identity, match, message)
+}
+
+/*
+SendToGroup Send a message on the OUTPUT pipes in a group.
+
+- parameter group: the label the pipes were registered with
+
+- parameter message: the IPipeMessage to send
+
+- returns: SendReport of whether the message was sent on each pipe in the group.
+*/
+func (self *JunctionSender) SendToGroup(group string, message interfaces.IPipeMessage) SendReport {
+	return self.junction.sendToGroup(self.identity, group, message)
+}
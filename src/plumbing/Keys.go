@@ -0,0 +1,34 @@
+//
+//  Keys.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+/*
+KeyProvider Source of the secret keys of fittings that sign or encrypt messages.
+
+Keys are looked up by ID, and the ID is carried in the
+metadata of each message, so that keys can be rotated: the
+sending fitting moves on to a new key ID while the receiving
+fitting still knows the old keys for messages in flight.
+*/
+type KeyProvider interface {
+	Key(id string) ([]byte, bool) // Get the key with an ID, false if there is none
+}
+
+/*
+StaticKeys KeyProvider of a fixed set of keys by ID.
+*/
+type StaticKeys map[string][]byte
+
+/*
+Key Get the key with an ID.
+*/
+func (self StaticKeys) Key(id string) ([]byte, bool) {
+	key, ok := self[id]
+	return key, ok
+}
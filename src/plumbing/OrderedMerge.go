@@ -0,0 +1,296 @@
+//
+//  OrderedMerge.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"container/heap"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+	"time"
+)
+
+/*
+OrderedMerge Timestamp-Ordered Merging Pipe Tee.
+
+Writes the normal messages from multiple input pipelines into
+a single output pipe fitting in the order of their event time,
+given by the Timestamp function or taken from the
+messages.METADATA_TIMESTAMP metadata if there is none.
+
+Messages are buffered until the watermark passes their
+timestamp. The watermark is the earliest of the latest
+timestamps seen on each input, less AllowedLateness, which is
+how far out of order an input may be. Messages with a
+timestamp before the watermark already written are late, and
+are written to the LateOutput instead, or dropped if there is
+none.
+
+Messages without a timestamp and control messages are
+written straight through. A messages.FLUSH control message
+writes out all buffered messages first.
+*/
+type OrderedMerge struct {
+	Pipe
+	LateOutput      interfaces.IPipeFitting                                 // Optional fitting late messages are written to
+	AllowedLateness time.Duration                                           // How far out of order each input may be
+	Timestamp       func(message interfaces.IPipeMessage) (time.Time, bool) // Event time of a message, nil for its timestamp metadata
+
+	inputs    []*orderedInput
+	buffered  timestampHeap
+	sequence  int
+	watermark time.Time
+	mutex     sync.Mutex
+}
+
+// orderedInput The fitting an input is connected to, which tracks the latest timestamp seen on the input.
+type orderedInput struct {
+	merge  *OrderedMerge
+	input  interfaces.IPipeFitting
+	latest time.Time
+	seen   bool
+}
+
+/*
+ConnectInput  Connect an input IPipeFitting.
+
+NOTE: You can connect as many inputs as you want
+by calling this method repeatedly. The watermark does not
+advance until every input has written a message.
+
+- parameter input: the IPipeFitting to connect for input.
+*/
+func (self *OrderedMerge) ConnectInput(input interfaces.IPipeFitting) bool {
+	adapter := &orderedInput{merge: self, input: input}
+	if !input.Connect(adapter) {
+		return false
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.inputs = append(self.inputs, adapter)
+	return true
+}
+
+/*
+DisconnectInput  Disconnect an input IPipeFitting.
+
+The watermark no longer waits for the input, which may
+release buffered messages.
+
+- parameter input: the IPipeFitting to disconnect.
+
+- returns: IPipeFitting the disconnected input, or nil if it was not connected.
+*/
+func (self *OrderedMerge) DisconnectInput(input interfaces.IPipeFitting) interfaces.IPipeFitting {
+	adapter := self.removeInput(input)
+	if adapter == nil {
+		return nil
+	}
+	if tee, ok := input.(interface {
+		DisconnectFitting(target interfaces.IPipeFitting) interfaces.IPipeFitting
+	}); ok { // inputs with several outputs, like a TeeSplit
+		tee.DisconnectFitting(adapter)
+	} else {
+		input.Disconnect()
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.release()
+	return input
+}
+
+// removeInput Stop tracking an input, returning the fitting it was connected to, or nil if it was not tracked.
+func (self *OrderedMerge) removeInput(input interfaces.IPipeFitting) *orderedInput {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	for index, adapter := range self.inputs {
+		if adapter.input == input {
+			self.inputs = append(self.inputs[:index], self.inputs[index+1:]...)
+			return adapter
+		}
+	}
+	return nil
+}
+
+/*
+Inputs  Get the connected inputs, in the order they were connected.
+*/
+func (self *OrderedMerge) Inputs() []interfaces.IPipeFitting {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	inputs := make([]interfaces.IPipeFitting, len(self.inputs))
+	for index, adapter := range self.inputs {
+		inputs[index] = adapter.input
+	}
+	return inputs
+}
+
+/*
+Watermark  Get the time up to which messages have been written out.
+*/
+func (self *OrderedMerge) Watermark() time.Time {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.watermark
+}
+
+/*
+Flush all buffered messages to the output in timestamp order.
+
+- returns: Bool true if all messages written successfully.
+*/
+func (self *OrderedMerge) Flush() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	success := true
+	for self.buffered.Len() > 0 {
+		item := heap.Pop(&self.buffered).(*timestamped)
+		if item.timestamp.After(self.watermark) {
+			self.watermark = item.timestamp
+		}
+		if self.Output.Write(item.message) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+// receive Handle a message from an input.
+func (self *OrderedMerge) receive(adapter *orderedInput, message interfaces.IPipeMessage) bool {
+	if message.Type() == messages.FLUSH {
+		success := self.Flush()
+		if self.Output.Write(message) == false {
+			success = false
+		}
+		return success
+	}
+
+	timestamp, ok := self.timestamp(message)
+	if message.Type() != messages.NORMAL || !ok {
+		return self.Output.Write(message)
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if timestamp.Before(self.watermark) {
+		if self.LateOutput == nil {
+			return false
+		}
+		return self.LateOutput.Write(message)
+	}
+
+	if !adapter.seen || timestamp.After(adapter.latest) {
+		adapter.latest = timestamp
+		adapter.seen = true
+	}
+	heap.Push(&self.buffered, &timestamped{timestamp: timestamp, sequence: self.sequence, message: message})
+	self.sequence++
+
+	return self.release()
+}
+
+// release Write out the buffered messages the watermark has passed. Caller holds the mutex.
+func (self *OrderedMerge) release() bool {
+	if len(self.inputs) == 0 {
+		return true
+	}
+	var watermark time.Time
+	for index, adapter := range self.inputs {
+		if !adapter.seen {
+			return true
+		}
+		if index == 0 || adapter.latest.Before(watermark) {
+			watermark = adapter.latest
+		}
+	}
+	watermark = watermark.Add(-self.AllowedLateness)
+	if watermark.After(self.watermark) {
+		self.watermark = watermark
+	}
+
+	success := true
+	for self.buffered.Len() > 0 && !self.buffered[0].timestamp.After(self.watermark) {
+		item := heap.Pop(&self.buffered).(*timestamped)
+		if self.Output.Write(item.message) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+// timestamp Get the event time of a message.
+func (self *OrderedMerge) timestamp(message interfaces.IPipeMessage) (time.Time, bool) {
+	if self.Timestamp != nil {
+		return self.Timestamp(message)
+	}
+	timestamp, ok := messages.Metadata(message, messages.METADATA_TIMESTAMP).(time.Time)
+	return timestamp, ok
+}
+
+/*
+Connect Can't connect anything to an input's side of the merge.
+*/
+func (self *orderedInput) Connect(output interfaces.IPipeFitting) bool {
+	return false
+}
+
+/*
+Disconnect Can't disconnect since you can't connect, either.
+*/
+func (self *orderedInput) Disconnect() interfaces.IPipeFitting {
+	return nil
+}
+
+/*
+Write a message from the input into the merge.
+*/
+func (self *orderedInput) Write(message interfaces.IPipeMessage) bool {
+	return self.merge.receive(self, message)
+}
+
+// timestamped A buffered message with its timestamp, and the order it arrived in to keep sorting stable.
+type timestamped struct {
+	timestamp time.Time
+	sequence  int
+	message   interfaces.IPipeMessage
+}
+
+// timestampHeap A min-heap of buffered messages by timestamp, then arrival.
+type timestampHeap []*timestamped
+
+func (h timestampHeap) Len() int {
+	return len(h)
+}
+func (h timestampHeap) Less(i, j int) bool {
+	if h[i].timestamp.Equal(h[j].timestamp) {
+		return h[i].sequence < h[j].sequence
+	}
+	return h[i].timestamp.Before(h[j].timestamp)
+}
+func (h timestampHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+func (h *timestampHeap) Push(x interface{}) {
+	*h = append(*h, x.(*timestamped))
+}
+func (h *timestampHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,256 @@
+//
+//  ParallelSplit.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"sync"
+)
+
+const laneBacklog = 128 // Messages that may wait for a slow output before its writers block
+
+/*
+ParallelSplit Parallel Splitting Pipe Tee.
+
+Writes input messages to multiple output pipe fittings
+concurrently, so that a slow output does not delay the
+others. Each output is written by its own goroutine, which
+takes the messages in the order they were written to the
+tee, so the order of messages is preserved per output. Once
+the backlog of a slow output is full, writes wait for it
+without holding up writes to the other outputs, and an output
+disconnected while a write waits for it counts as failed.
+
+At most Workers outputs are written at the same time, or
+all of them if Workers is zero.
+
+A write waits until Quorum outputs have taken the message,
+or all of them if Quorum is zero, and reports which outputs
+succeeded, failed, or had not finished yet. Outputs that had
+not finished keep writing in the background, and the Failure
+function, if set, is called for every output that fails.
+*/
+type ParallelSplit struct {
+	TeeSplit
+	Workers int                                                                   // Outputs written at the same time, 0 for all
+	Quorum  int                                                                   // Outputs that must take a message, 0 for all
+	Failure func(output interfaces.IPipeFitting, message interfaces.IPipeMessage) // Optional callback for every failed output write
+
+	lanes      map[interfaces.IPipeFitting]*splitLane
+	workers    chan struct{}
+	lanesMutex sync.Mutex
+}
+
+/*
+SplitReport Outcome of a ParallelSplit write.
+*/
+type SplitReport struct {
+	Success   bool                      // Whether the quorum took the message
+	Succeeded []interfaces.IPipeFitting // Outputs that took the message
+	Failed    []interfaces.IPipeFitting // Outputs that failed to take the message
+	Pending   []interfaces.IPipeFitting // Outputs still writing when the quorum was decided
+}
+
+type splitLane struct {
+	jobs    chan splitJob
+	done    chan struct{}  // Closed when the output is disconnected
+	senders sync.WaitGroup // Writes still handing a message to the lane
+}
+
+type splitJob struct {
+	index   int
+	message interfaces.IPipeMessage
+	results chan<- splitResult
+}
+
+type splitResult struct {
+	index int
+	ok    bool
+}
+
+/*
+Disconnect the most recently connected output fitting. (LIFO)
+
+Its goroutine stops once it has written the messages
+already waiting for it.
+*/
+func (self *ParallelSplit) Disconnect() interfaces.IPipeFitting {
+	self.lanesMutex.Lock()
+	defer self.lanesMutex.Unlock()
+
+	disconnected := self.TeeSplit.Disconnect()
+	self.release(disconnected)
+	return disconnected
+}
+
+/*
+DisconnectFitting Disconnect a given output fitting.
+
+Its goroutine stops once it has written the messages
+already waiting for it.
+*/
+func (self *ParallelSplit) DisconnectFitting(target interfaces.IPipeFitting) interfaces.IPipeFitting {
+	self.lanesMutex.Lock()
+	defer self.lanesMutex.Unlock()
+
+	disconnected := self.TeeSplit.DisconnectFitting(target)
+	self.release(disconnected)
+	return disconnected
+}
+
+/*
+Write the message to all connected outputs concurrently.
+
+- parameter message: the message to write
+
+- returns: Boolean whether the quorum of outputs took the message
+*/
+func (self *ParallelSplit) Write(message interfaces.IPipeMessage) bool {
+	return self.WriteReport(message).Success
+}
+
+/*
+WriteReport Write the message to all connected outputs concurrently.
+
+- parameter message: the message to write
+
+- returns: *SplitReport which outputs succeeded, failed or were still writing
+*/
+func (self *ParallelSplit) WriteReport(message interfaces.IPipeMessage) *SplitReport {
+	self.lanesMutex.Lock()
+	self.outputsMutex.RLock()
+	outputs := make([]interfaces.IPipeFitting, len(self.outputs))
+	copy(outputs, self.outputs)
+	self.outputsMutex.RUnlock()
+
+	lanes := make([]*splitLane, len(outputs))
+	for index, output := range outputs {
+		lanes[index] = self.lane(output)
+		lanes[index].senders.Add(1)
+	}
+	self.lanesMutex.Unlock()
+
+	// hand the message to every lane with room first, then wait for the full ones
+	results := make(chan splitResult, len(outputs))
+	var full []int
+	for index, lane := range lanes {
+		select {
+		case lane.jobs <- splitJob{index: index, message: message, results: results}:
+			lane.senders.Done()
+		default:
+			full = append(full, index)
+		}
+	}
+	for _, index := range full {
+		self.send(lanes[index], splitJob{index: index, message: message, results: results})
+	}
+
+	required := len(outputs)
+	if self.Quorum > 0 && self.Quorum < required {
+		required = self.Quorum
+	}
+
+	// without a quorum wait for every output, otherwise only until the quorum is reached or out of reach
+	report := &SplitReport{}
+	done := make([]bool, len(outputs))
+	for len(report.Succeeded)+len(report.Failed) < len(outputs) {
+		if self.Quorum > 0 && (len(report.Succeeded) >= required || len(report.Failed) > len(outputs)-required) {
+			break
+		}
+		result := <-results
+		done[result.index] = true
+		if result.ok {
+			report.Succeeded = append(report.Succeeded, outputs[result.index])
+		} else {
+			report.Failed = append(report.Failed, outputs[result.index])
+		}
+	}
+	for index, output := range outputs {
+		if !done[index] {
+			report.Pending = append(report.Pending, output)
+		}
+	}
+	report.Success = len(report.Succeeded) >= required
+	return report
+}
+
+// lane Get the lane of an output, starting its goroutine if needed. Caller holds lanesMutex.
+func (self *ParallelSplit) lane(output interfaces.IPipeFitting) *splitLane {
+	if self.lanes == nil {
+		self.lanes = make(map[interfaces.IPipeFitting]*splitLane)
+	}
+	if self.workers == nil && self.Workers > 0 {
+		self.workers = make(chan struct{}, self.Workers)
+	}
+
+	lane, ok := self.lanes[output]
+	if !ok {
+		lane = &splitLane{jobs: make(chan splitJob, laneBacklog), done: make(chan struct{})}
+		self.lanes[output] = lane
+		go self.run(output, lane, self.workers)
+	}
+	return lane
+}
+
+// send Wait for room in the lane for the job, failing it if the output is disconnected meanwhile.
+func (self *ParallelSplit) send(lane *splitLane, job splitJob) {
+	defer lane.senders.Done()
+
+	select {
+	case lane.jobs <- job:
+	case <-lane.done:
+		job.results <- splitResult{index: job.index, ok: false}
+	}
+}
+
+// run Write the messages of a lane to its output in order.
+func (self *ParallelSplit) run(output interfaces.IPipeFitting, lane *splitLane, workers chan struct{}) {
+	for job := range lane.jobs {
+		if workers != nil {
+			workers <- struct{}{}
+		}
+		ok := output.Write(job.message)
+		if workers != nil {
+			<-workers
+		}
+		if !ok && self.Failure != nil {
+			self.Failure(output, job.message)
+		}
+		job.results <- splitResult{index: job.index, ok: ok}
+	}
+}
+
+// release Stop the lane of a disconnected output unless it is still connected elsewhere. Caller holds lanesMutex.
+func (self *ParallelSplit) release(output interfaces.IPipeFitting) {
+	lane, ok := self.lanes[output]
+	if output == nil || !ok {
+		return
+	}
+	if !self.connected(output) {
+		close(lane.done)
+		go func() {
+			lane.senders.Wait()
+			close(lane.jobs)
+		}()
+		delete(self.lanes, output)
+	}
+}
+
+// connected Is the output still connected?
+func (self *ParallelSplit) connected(output interfaces.IPipeFitting) bool {
+	self.outputsMutex.RLock()
+	defer self.outputsMutex.RUnlock()
+
+	for _, connected := range self.outputs {
+		if connected == output {
+			return true
+		}
+	}
+	return false
+}
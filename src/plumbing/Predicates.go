@@ -0,0 +1,79 @@
+//
+//  Predicates.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import "github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+
+/*
+Predicate A filter function, as used by the Filter and FilterChain.
+
+Returns true if the message passes the filter.
+*/
+type Predicate = func(message interfaces.IPipeMessage, params interface{}) bool
+
+/*
+And Combine two predicates into one that passes a message only if both pass it.
+
+The second predicate is not evaluated if the first fails.
+*/
+func And(first Predicate, second Predicate) Predicate {
+	return All(first, second)
+}
+
+/*
+Or Combine two predicates into one that passes a message if either passes it.
+
+The second predicate is not evaluated if the first passes.
+*/
+func Or(first Predicate, second Predicate) Predicate {
+	return Any(first, second)
+}
+
+/*
+Not Invert a predicate.
+*/
+func Not(predicate Predicate) Predicate {
+	return func(message interfaces.IPipeMessage, params interface{}) bool {
+		return !predicate(message, params)
+	}
+}
+
+/*
+All Combine predicates into one that passes a message only if all of them pass it.
+
+The predicates are evaluated in order until one fails. With no
+predicates every message passes.
+*/
+func All(predicates ...Predicate) Predicate {
+	return func(message interfaces.IPipeMessage, params interface{}) bool {
+		for _, predicate := range predicates {
+			if !predicate(message, params) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+/*
+Any Combine predicates into one that passes a message if any of them passes it.
+
+The predicates are evaluated in order until one passes. With no
+predicates no message passes.
+*/
+func Any(predicates ...Predicate) Predicate {
+	return func(message interfaces.IPipeMessage, params interface{}) bool {
+		for _, predicate := range predicates {
+			if predicate(message, params) {
+				return true
+			}
+		}
+		return false
+	}
+}
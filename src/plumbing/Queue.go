@@ -23,19 +23,32 @@ a FLUSH control message, at which point it writes it's buffer
 to the output pipe fitting. The Queue can be sent a SORT
 control message to go into sort-by-priority mode or a FIFO
 control message to cancel sort mode and return the
-default mode of operation, FIFO.
+default mode of operation, FIFO. In sort mode messages are
+ordered by the Comparator, if set, instead of by priority,
+keeping messages that compare equal in the order they arrived.
 
-NOTE: There can effectively be only one Queue on a given
-pipeline, since the first Queue acts on any queue control
-message. Multiple queues in one pipeline are of dubious
-use, and so having to name them would make their operation
-more complex than need be.
+The Queue can also act as a gate: a RESUME control message
+flushes the stored messages and opens the gate, so that normal
+messages pass straight through to the output, in order even
+with concurrent writers, until a PAUSE control message closes
+the gate again and the Queue resumes storing them.
+
+A Queue acts only on queue control messages addressed to its
+Name, and writes those addressed to other queues through. An
+unnamed Queue acts on unaddressed messages, so a pipeline with
+a single Queue needs no names, while pipelines with several
+queues in series, such as a staging queue and a priority
+queue, name them to control each one separately.
 */
 type Queue struct {
 	Pipe
+	Name          string
 	Mode          string
+	Comparator    Comparator // Order of messages in sort mode, nil for by priority
 	Messages      []interfaces.IPipeMessage
 	MessagesMutex sync.Mutex
+
+	resumed bool
 }
 
 /**
@@ -54,13 +67,42 @@ type Queue struct {
  * Sorting-by-priority behavior continues even after a FLUSH,
  * and can be turned off by sending a FIFO message, which is
  * the default behavior for enqueue/dequeue.
+ *
+ * The FLUSH_N message type tells the Queue to write only the
+ * first Count stored messages, and the FLUSH_WHERE message type
+ * only the stored messages selected by the QueueControlMessage.
+ * The PURGE message type tells the Queue to discard the selected
+ * messages, or all messages if none are selected.
+ *
+ * The SET_COMPARATOR message type tells the Queue to sort
+ * by the Comparator of the QueueControlMessage, or by priority
+ * if it has none.
+ *
+ * The RESUME message type tells the Queue to flush, then write
+ * subsequent normal messages straight through, until the PAUSE
+ * message type tells it to store them again.
+ *
+ * Queue control messages addressed to another queue are
+ * written through.
+ *
+ * The STATUS message type tells the Queue to report its mode
+ * and length to the query, if it is addressed to the Queue,
+ * and write the query through without queueing it.
  */
 func (self *Queue) Write(message interfaces.IPipeMessage) bool {
 	success := true
 
 	switch message.Type() {
-	case messages.NORMAL: // Store normal messages
-		self.Store(message)
+	case messages.FLUSH, messages.SORT, messages.FIFO, messages.PAUSE, messages.RESUME,
+		messages.FLUSH_N, messages.FLUSH_WHERE, messages.PURGE, messages.SET_COMPARATOR:
+		if !self.IsTarget(message) {
+			return self.Pipe.Write(message) // addressed to another queue
+		}
+	}
+
+	switch message.Type() {
+	case messages.NORMAL: // Store normal messages, or pass them through if resumed
+		success = self.admit(message)
 
 	case messages.FLUSH: // Flush the queue
 		success = self.Flush()
@@ -72,10 +114,56 @@ func (self *Queue) Write(message interfaces.IPipeMessage) bool {
 		fallthrough
 	case messages.FIFO:
 		self.Mode = message.Type()
+	case messages.SET_COMPARATOR: // Sort with the comparator from control message
+		if control, ok := message.(*messages.QueueControlMessage); ok {
+			self.SetComparator(control.Comparator())
+		} else {
+			success = false // malformed control message
+		}
+	case messages.PAUSE: // Close or open the gate
+		self.Pause()
+	case messages.RESUME:
+		success = self.Resume()
+	case messages.FLUSH_N: // Flush part of the queue
+		if control, ok := message.(*messages.QueueControlMessage); ok {
+			success = self.FlushN(control.Count())
+		} else {
+			success = false // malformed control message
+		}
+	case messages.FLUSH_WHERE:
+		if where, ok := self.where(message); ok && where != nil {
+			success = self.FlushWhere(where)
+		} else {
+			success = false // malformed control message
+		}
+	case messages.PURGE: // Discard part of the queue
+		if where, ok := self.where(message); ok {
+			if where == nil {
+				where = func(interfaces.IPipeMessage) bool { return true }
+			}
+			self.RemoveWhere(where)
+		} else {
+			success = false // malformed control message
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Queue", self.Status) {
+			success = self.Pipe.Write(message)
+		} else {
+			success = false // malformed status query
+		}
 	}
 	return success
 }
 
+// IsTarget Is the control message directed at this queue instance? Unaddressed messages are directed at unnamed queues.
+func (self *Queue) IsTarget(message interfaces.IPipeMessage) bool {
+	name := ""
+	if control, ok := message.(*messages.QueueControlMessage); ok {
+		name = control.Name()
+	}
+	return name == self.Name
+}
+
 /*
 Store a message.
 
@@ -85,12 +173,44 @@ func (self *Queue) Store(message interfaces.IPipeMessage) {
 	self.MessagesMutex.Lock()
 	defer self.MessagesMutex.Unlock()
 
+	self.store(message)
+}
+
+// store Append a message, sorting if in sort mode. Caller holds MessagesMutex.
+func (self *Queue) store(message interfaces.IPipeMessage) {
 	self.Messages = append(self.Messages, message)
 	if self.Mode == messages.SORT {
-		sort.Sort(SortByPriority(self.Messages))
+		self.sort()
 	}
 }
 
+// sort Sort the stored messages by the comparator, keeping the order of equal messages. Caller holds MessagesMutex.
+func (self *Queue) sort() {
+	comparator := self.Comparator
+	if comparator == nil {
+		comparator = ByPriority
+	}
+	sort.SliceStable(self.Messages, func(i, j int) bool {
+		return comparator(self.Messages[i], self.Messages[j]) < 0
+	})
+}
+
+/*
+SetComparator Put the queue into sort mode, ordering messages with a comparator.
+
+The stored messages are sorted at once.
+
+- parameter comparator: the Comparator, nil for by priority
+*/
+func (self *Queue) SetComparator(comparator Comparator) {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	self.Comparator = comparator
+	self.Mode = messages.SORT
+	self.sort()
+}
+
 /*
 SortByPriority Sort the Messages by priority.
 */
@@ -136,3 +256,204 @@ func (self *Queue) Flush() bool {
 	}
 	return success
 }
+
+/*
+Status Get the mode of the queue, the number of messages it holds, and whether it is paused.
+*/
+func (self *Queue) Status() map[string]interface{} {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	return map[string]interface{}{"mode": self.Mode, "length": len(self.Messages), "paused": !self.resumed}
+}
+
+/*
+Pause Close the gate, storing subsequent normal messages.
+*/
+func (self *Queue) Pause() {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	self.resumed = false
+}
+
+/*
+Resume Flush the stored messages, then open the gate, passing subsequent normal messages through.
+
+Writers wait while the backlog is flushed, so no message
+overtakes one stored before it.
+
+- returns: Bool true if all stored messages written successfully.
+*/
+func (self *Queue) Resume() bool {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	success := true
+	for _, message := range self.Messages {
+		if self.Pipe.Write(message) == false {
+			success = false
+		}
+	}
+	self.Messages = nil
+	self.resumed = true
+	return success
+}
+
+/*
+Paused Is the gate closed, so that normal messages are stored?
+*/
+func (self *Queue) Paused() bool {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	return !self.resumed
+}
+
+// admit Store a normal message, or write it through if the gate is open and nothing is stored.
+func (self *Queue) admit(message interfaces.IPipeMessage) bool {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	if self.resumed && len(self.Messages) == 0 {
+		return self.Pipe.Write(message)
+	}
+	self.store(message)
+	return true
+}
+
+/*
+Len Get the number of stored messages.
+*/
+func (self *Queue) Len() int {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	return len(self.Messages)
+}
+
+/*
+Peek Get the first stored messages without removing them.
+
+- parameter n: the number of messages to get, at most
+
+- returns: a copy of the first n stored messages, in the order they would be flushed.
+*/
+func (self *Queue) Peek(n int) []interfaces.IPipeMessage {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	if n > len(self.Messages) {
+		n = len(self.Messages)
+	}
+	if n < 0 {
+		n = 0
+	}
+	peeked := make([]interfaces.IPipeMessage, n)
+	copy(peeked, self.Messages)
+	return peeked
+}
+
+/*
+Snapshot Get all stored messages without removing them.
+
+- returns: a copy of the stored messages, in the order they would be flushed.
+*/
+func (self *Queue) Snapshot() []interfaces.IPipeMessage {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	snapshot := make([]interfaces.IPipeMessage, len(self.Messages))
+	copy(snapshot, self.Messages)
+	return snapshot
+}
+
+/*
+RemoveWhere Discard the stored messages a predicate selects.
+
+- parameter predicate: returns true for the messages to discard
+
+- returns: the discarded messages, in order.
+*/
+func (self *Queue) RemoveWhere(predicate func(message interfaces.IPipeMessage) bool) []interfaces.IPipeMessage {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	removed, kept := self.partition(predicate)
+	self.Messages = kept
+	return removed
+}
+
+/*
+FlushN Write the first stored messages to the output.
+
+- parameter n: the number of messages to write, at most
+
+- returns: Bool true if all messages written successfully.
+*/
+func (self *Queue) FlushN(n int) bool {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	success := true
+	for ; n > 0 && len(self.Messages) > 0; n-- {
+		message := self.Messages[0]
+		self.Messages = self.Messages[1:]
+		if self.Pipe.Write(message) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+/*
+FlushWhere Write the stored messages a predicate selects to the output, keeping the others.
+
+- parameter predicate: returns true for the messages to write
+
+- returns: Bool true if all messages written successfully.
+*/
+func (self *Queue) FlushWhere(predicate func(message interfaces.IPipeMessage) bool) bool {
+	self.MessagesMutex.Lock()
+	defer self.MessagesMutex.Unlock()
+
+	selected, kept := self.partition(predicate)
+	self.Messages = kept
+
+	success := true
+	for _, message := range selected {
+		if self.Pipe.Write(message) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+// partition Split the stored messages into those a predicate selects and the others, keeping their order. Caller holds MessagesMutex.
+func (self *Queue) partition(predicate func(message interfaces.IPipeMessage) bool) (selected []interfaces.IPipeMessage, kept []interfaces.IPipeMessage) {
+	kept = make([]interfaces.IPipeMessage, 0, len(self.Messages))
+	for _, message := range self.Messages {
+		if predicate(message) {
+			selected = append(selected, message)
+		} else {
+			kept = append(kept, message)
+		}
+	}
+	return selected, kept
+}
+
+// where Get the function selecting messages of a queue control message, compiling its expression if it has no function.
+func (self *Queue) where(message interfaces.IPipeMessage) (func(message interfaces.IPipeMessage) bool, bool) {
+	control, ok := message.(*messages.QueueControlMessage)
+	if !ok {
+		return nil, false
+	}
+	if control.Where() != nil || control.Expression() == "" {
+		return control.Where(), true
+	}
+	expression, err := CompileExpression(control.Expression())
+	if err != nil {
+		return nil, false
+	}
+	return func(message interfaces.IPipeMessage) bool { return expression.Match(message, nil) }, true
+}
@@ -0,0 +1,317 @@
+//
+//  Resequencer.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	GAP_SKIP   = "skip"   // Skip missing sequence numbers silently (default behavior)
+	GAP_NOTIFY = "notify" // Write a SequenceGapMessage before skipping missing sequence numbers
+
+	STREAM_TIMEOUT = 10 * time.Minute // How long a Resequencer remembers an idle stream by default
+)
+
+/*
+Resequencer Pipe Resequencer.
+
+Restores the order of normal messages that arrived out of
+order. Each message carries a sequence number, given by the
+Sequence function or taken from an int header if there is
+none. Messages are buffered and written to the output strictly
+in sequence order, starting from First.
+
+If a Stream function is set, each stream ID it returns is
+sequenced independently. A stream with nothing buffered that
+made no progress for StreamTimeout is forgotten as new streams
+arrive, and starts from First again if it comes back.
+
+If the next sequence number of a stream has not arrived
+GapTimeout after the stream last made progress, the missing
+numbers are skipped and the buffered messages released. In
+GAP_NOTIFY mode a messages.SequenceGapMessage is written to
+the output first.
+
+Messages with a sequence number that was already written or
+skipped are dropped. Messages without a sequence number are
+written straight through.
+
+Messages are written to the output outside the lock, one
+batch of a stream at a time, so a slow output does not hold
+up other streams, and an output may write to the same
+resequencer. Messages of a stream released while a batch of
+it is written are written after it.
+
+A messages.FLUSH control message writes out all buffered
+messages in order regardless of gaps, then is written through
+to the output. All other control messages are written through
+unchanged.
+*/
+type Resequencer struct {
+	Pipe
+	First         int                                               // First sequence number of each stream
+	GapTimeout    time.Duration                                     // How long to wait for a missing sequence number, 0 to wait forever
+	StreamTimeout time.Duration                                     // How long an idle stream is remembered, 0 for STREAM_TIMEOUT, negative for ever
+	Mode          string                                            // GAP_SKIP or GAP_NOTIFY
+	Sequence      func(message interfaces.IPipeMessage) (int, bool) // Sequence number of a message, nil for an int header
+	Stream        func(message interfaces.IPipeMessage) string      // Optional stream ID of a message, nil for a single stream
+	Clock         Clock                                             // Time source, nil for the system clock
+
+	streams map[string]*sequence
+	swept   int // Number of streams after the last sweep for idle streams
+	mutex   sync.Mutex
+}
+
+type sequence struct {
+	next     int
+	buffered map[int]interfaces.IPipeMessage
+	timer    Timer
+	last     time.Time                 // When the stream last made progress
+	outbox   []interfaces.IPipeMessage // Messages released to be written, in order
+	writing  bool                      // The outbox is being written out
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are buffered until all messages before
+them in their stream have been written.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if the message is a late duplicate
+or a write to the output failed.
+*/
+func (self *Resequencer) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Resequence normal messages
+		number, ok := self.sequence(message)
+		if !ok {
+			success = self.Output.Write(message)
+			break
+		}
+		stream := ""
+		if self.Stream != nil {
+			stream = self.Stream(message)
+		}
+		success = self.Buffer(stream, number, message)
+	case messages.FLUSH: // Write out buffered messages, then let the flush through
+		success = self.Flush()
+		if self.Output.Write(message) == false {
+			success = false
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+/*
+Buffer a message and write out all messages of its stream now in sequence.
+
+- parameter stream: the stream ID
+
+- parameter number: the sequence number of the message
+
+- parameter message: the IPipeMessage to buffer
+
+- returns: Bool false if the message is a late duplicate or a write to the output failed.
+*/
+func (self *Resequencer) Buffer(stream string, number int, message interfaces.IPipeMessage) bool {
+	self.mutex.Lock()
+	current := self.stream(stream)
+	if _, buffered := current.buffered[number]; number < current.next || buffered {
+		self.mutex.Unlock()
+		return false
+	}
+	current.buffered[number] = message
+
+	self.release(stream, current)
+	return self.writeOut(current)
+}
+
+/*
+Flush all buffered messages to the output in sequence order, skipping gaps.
+
+If messages of a stream are being written out already, its
+buffered messages are written after them by that write out.
+
+- returns: Bool true if all messages written successfully.
+*/
+func (self *Resequencer) Flush() bool {
+	self.mutex.Lock()
+	flushed := make([]*sequence, 0, len(self.streams))
+	for _, current := range self.streams {
+		if current.timer != nil {
+			current.timer.Stop()
+			current.timer = nil
+		}
+		numbers := make([]int, 0, len(current.buffered))
+		for number := range current.buffered {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		for _, number := range numbers {
+			current.outbox = append(current.outbox, current.buffered[number])
+			delete(current.buffered, number)
+			current.next = number + 1
+		}
+		if len(numbers) > 0 {
+			current.last = clockOrSystem(self.Clock).Now()
+			flushed = append(flushed, current)
+		}
+	}
+	self.mutex.Unlock()
+
+	success := true
+	for _, current := range flushed {
+		self.mutex.Lock()
+		if self.writeOut(current) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+/*
+Streams Get the number of streams remembered.
+*/
+func (self *Resequencer) Streams() int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return len(self.streams)
+}
+
+// stream Get the state of a stream, starting it if it is new. Caller holds the mutex.
+func (self *Resequencer) stream(stream string) *sequence {
+	if self.streams == nil {
+		self.streams = make(map[string]*sequence)
+	}
+	current, ok := self.streams[stream]
+	if !ok {
+		now := clockOrSystem(self.Clock).Now()
+		if len(self.streams) >= 2*self.swept {
+			self.sweep(now)
+		}
+		current = &sequence{next: self.First, buffered: make(map[int]interfaces.IPipeMessage), last: now}
+		self.streams[stream] = current
+	}
+	return current
+}
+
+// sweep Drop the streams with nothing buffered or being written that made no progress for the stream timeout. Caller holds the mutex.
+func (self *Resequencer) sweep(now time.Time) {
+	timeout := self.StreamTimeout
+	if timeout == 0 {
+		timeout = STREAM_TIMEOUT
+	}
+	if timeout > 0 {
+		for stream, current := range self.streams {
+			if len(current.buffered) == 0 && current.timer == nil && len(current.outbox) == 0 && !current.writing &&
+				now.Sub(current.last) >= timeout {
+				delete(self.streams, stream)
+			}
+		}
+	}
+	self.swept = len(self.streams)
+}
+
+// release Move the messages now in sequence to the outbox and rearm the gap timer. Caller holds the mutex.
+func (self *Resequencer) release(stream string, current *sequence) {
+	progressed := false
+	for message, ok := current.buffered[current.next]; ok; message, ok = current.buffered[current.next] {
+		current.outbox = append(current.outbox, message)
+		delete(current.buffered, current.next)
+		current.next++
+		progressed = true
+	}
+	if progressed {
+		current.last = clockOrSystem(self.Clock).Now()
+	}
+
+	if current.timer != nil && (progressed || len(current.buffered) == 0) {
+		current.timer.Stop()
+		current.timer = nil
+	}
+	if current.timer == nil && len(current.buffered) > 0 && self.GapTimeout > 0 {
+		var timer Timer
+		timer = clockOrSystem(self.Clock).AfterFunc(self.GapTimeout, func() { self.skip(stream, current, timer) })
+		current.timer = timer
+	}
+}
+
+// writeOut Write the outbox of a stream to the output outside the mutex, unless a write out of the stream is under way, which takes it over. Caller holds the mutex, which is released.
+func (self *Resequencer) writeOut(current *sequence) bool {
+	if current.writing {
+		self.mutex.Unlock()
+		return true
+	}
+	current.writing = true
+	success := true
+	for len(current.outbox) > 0 {
+		batch := current.outbox
+		current.outbox = nil
+		self.mutex.Unlock()
+
+		for _, message := range batch {
+			if self.Output.Write(message) == false {
+				success = false
+			}
+		}
+
+		self.mutex.Lock()
+	}
+	current.writing = false
+	self.mutex.Unlock()
+	return success
+}
+
+// skip Give up on the missing sequence numbers of a stream whose gap timer fired.
+func (self *Resequencer) skip(stream string, current *sequence, timer Timer) {
+	self.mutex.Lock()
+	if current.timer != timer || len(current.buffered) == 0 {
+		self.mutex.Unlock()
+		return
+	}
+	current.timer = nil
+
+	lowest := 0
+	first := true
+	for number := range current.buffered {
+		if first || number < lowest {
+			lowest = number
+			first = false
+		}
+	}
+
+	if self.Mode == GAP_NOTIFY {
+		current.outbox = append(current.outbox, messages.NewSequenceGapMessage(stream, current.next, lowest-1))
+	}
+	current.next = lowest
+	self.release(stream, current)
+	self.writeOut(current)
+}
+
+// sequence Get the sequence number of a message.
+func (self *Resequencer) sequence(message interfaces.IPipeMessage) (int, bool) {
+	if self.Sequence != nil {
+		return self.Sequence(message)
+	}
+	number, ok := message.Header().(int)
+	return number, ok
+}
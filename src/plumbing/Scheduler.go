@@ -0,0 +1,298 @@
+//
+//  Scheduler.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+	"time"
+)
+
+/*
+Scheduler Pipe Scheduler.
+
+Holds normal messages until they are due, then writes them to
+the output. A message is due at the time.Time in its
+messages.METADATA_DELIVER_AT metadata, or once the
+time.Duration in its messages.METADATA_DELAY metadata has
+passed since it was written. Messages due at the same time
+are written in the order they were scheduled.
+
+Messages that are already due, or carry neither metadata, are
+written straight through.
+
+Scheduled messages can be cancelled by a
+SchedulerControlMessage with the messages.CANCEL type
+addressed to the Name of the scheduler, which discards the
+messages with its message ID, given by the ID function or
+taken from the messages.METADATA_ID metadata.
+
+A messages.FLUSH control message writes out all scheduled
+messages at once, then is written through. All other control
+messages are written through unchanged.
+
+Messages are written to the output outside the lock, one batch
+at a time, so an output may schedule messages on the same
+scheduler, as a retry does. Messages that become due while a
+batch is written are written after it.
+*/
+type Scheduler struct {
+	Pipe
+	Name  string
+	ID    func(message interfaces.IPipeMessage) string // Message ID for cancellation, nil for its message ID metadata
+	Clock Clock                                        // Time source, nil for the system clock
+
+	scheduled  timestampHeap
+	sequence   int
+	timer      Timer
+	generation int
+	outbox     []interfaces.IPipeMessage // Messages taken to be written, in order
+	delivering bool                      // The outbox is being written out
+	mutex      sync.Mutex
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are held until they are due.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if a write to the output failed, or
+a cancellation had no message ID or found no scheduled message.
+*/
+func (self *Scheduler) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Hold normal messages until due
+		if at, ok := self.due(message); ok {
+			success = self.Schedule(message, at)
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.CANCEL: // Cancel scheduled messages from control message
+		if control, ok := message.(*messages.SchedulerControlMessage); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			success = self.Cancel(control.ID()) > 0
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.FLUSH: // Write out scheduled messages, then let the flush through
+		success = self.flush(message)
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Scheduler", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+// IsTarget Is the message directed at this scheduler instance?
+func (self *Scheduler) IsTarget(message interfaces.IPipeMessage) bool {
+	control, ok := message.(*messages.SchedulerControlMessage)
+	return ok && control.Name() == self.Name
+}
+
+/*
+Schedule a message for delivery.
+
+- parameter message: the IPipeMessage to deliver
+
+- parameter at: when to deliver it
+
+- returns: Bool false if the message was already due and the output failed to take it.
+*/
+func (self *Scheduler) Schedule(message interfaces.IPipeMessage, at time.Time) bool {
+	self.mutex.Lock()
+	if !at.After(clockOrSystem(self.Clock).Now()) && self.scheduled.Len() == 0 {
+		self.outbox = append(self.outbox, message)
+		return self.writeOut()
+	}
+	heap.Push(&self.scheduled, &timestamped{timestamp: at, sequence: self.sequence, message: message})
+	self.sequence++
+	self.schedule()
+	self.mutex.Unlock()
+	return true
+}
+
+/*
+Cancel the delivery of the scheduled messages with a message ID.
+
+- parameter id: the message ID
+
+- returns: the number of messages cancelled, 0 if the message ID is empty.
+*/
+func (self *Scheduler) Cancel(id string) int {
+	if id == "" {
+		return 0 // messages without an ID can't be told apart
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	kept := self.scheduled[:0]
+	cancelled := 0
+	for _, item := range self.scheduled {
+		if self.id(item.message) == id {
+			cancelled++
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	for index := len(kept); index < len(self.scheduled); index++ {
+		self.scheduled[index] = nil
+	}
+	self.scheduled = kept
+	heap.Init(&self.scheduled)
+	self.schedule()
+	return cancelled
+}
+
+/*
+Pending Get the number of scheduled messages.
+*/
+func (self *Scheduler) Pending() int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.scheduled.Len()
+}
+
+/*
+Flush all scheduled messages to the output in the order they are due.
+
+If messages are being written out already, the scheduled
+messages are written after them by that write out.
+
+- returns: Bool true if all messages written successfully.
+*/
+func (self *Scheduler) Flush() bool {
+	return self.flush(nil)
+}
+
+/*
+Status Get the number of scheduled messages and when the next is due.
+*/
+func (self *Scheduler) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	status := map[string]interface{}{"pending": self.scheduled.Len()}
+	if self.scheduled.Len() > 0 {
+		status["next"] = self.scheduled[0].timestamp
+	}
+	return status
+}
+
+// flush Write out all scheduled messages, then the flush control message if there is one.
+func (self *Scheduler) flush(message interfaces.IPipeMessage) bool {
+	self.mutex.Lock()
+	for self.scheduled.Len() > 0 {
+		self.outbox = append(self.outbox, heap.Pop(&self.scheduled).(*timestamped).message)
+	}
+	if message != nil {
+		self.outbox = append(self.outbox, message)
+	}
+	return self.writeOut()
+}
+
+// deliver Write out the scheduled messages that are due.
+func (self *Scheduler) deliver(generation int) {
+	self.mutex.Lock()
+	if generation != self.generation {
+		self.mutex.Unlock()
+		return
+	}
+	self.timer = nil
+	self.takeDue()
+	self.writeOut()
+}
+
+// takeDue Move the scheduled messages that are due to the outbox. Caller holds the mutex.
+func (self *Scheduler) takeDue() {
+	now := clockOrSystem(self.Clock).Now()
+	for self.scheduled.Len() > 0 && !self.scheduled[0].timestamp.After(now) {
+		self.outbox = append(self.outbox, heap.Pop(&self.scheduled).(*timestamped).message)
+	}
+}
+
+// writeOut Write the outbox to the output outside the mutex, unless a write out is under way, which takes it over. Caller holds the mutex, which is released.
+func (self *Scheduler) writeOut() bool {
+	if self.delivering {
+		self.mutex.Unlock()
+		return true
+	}
+	self.delivering = true
+	success := true
+	for len(self.outbox) > 0 {
+		batch := self.outbox
+		self.outbox = nil
+		self.mutex.Unlock()
+
+		for _, message := range batch {
+			if self.Output.Write(message) == false {
+				success = false
+			}
+		}
+
+		self.mutex.Lock()
+		self.takeDue()
+	}
+	self.delivering = false
+	self.schedule()
+	self.mutex.Unlock()
+	return success
+}
+
+// schedule Arm the timer for the earliest scheduled message. Caller holds the mutex.
+func (self *Scheduler) schedule() {
+	if self.timer != nil {
+		self.timer.Stop()
+		self.timer = nil
+	}
+	self.generation++
+	if self.scheduled.Len() == 0 {
+		return
+	}
+	clock := clockOrSystem(self.Clock)
+	generation := self.generation
+	self.timer = clock.AfterFunc(self.scheduled[0].timestamp.Sub(clock.Now()), func() { self.deliver(generation) })
+}
+
+// due Get when a message is due from its metadata.
+func (self *Scheduler) due(message interfaces.IPipeMessage) (time.Time, bool) {
+	if at, ok := messages.Metadata(message, messages.METADATA_DELIVER_AT).(time.Time); ok {
+		return at, true
+	}
+	if delay, ok := messages.Metadata(message, messages.METADATA_DELAY).(time.Duration); ok {
+		return clockOrSystem(self.Clock).Now().Add(delay), true
+	}
+	return time.Time{}, false
+}
+
+// id Get the message ID of a message.
+func (self *Scheduler) id(message interfaces.IPipeMessage) string {
+	if self.ID != nil {
+		return self.ID(message)
+	}
+	if id := messages.Metadata(message, messages.METADATA_ID); id != nil {
+		return fmt.Sprint(id)
+	}
+	return ""
+}
@@ -0,0 +1,308 @@
+//
+//  Signer.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+Signer Pipe Signer.
+
+Signs normal messages so that a Verifier on the other side of
+a process boundary can tell they were not tampered with. The
+signature is an HMAC-SHA256, keyed with the key of KeyID from
+Keys, over a canonical serialization of the key ID, the type,
+header, body and priority of the message, and the sender it
+claims in its messages.METADATA_SENDER metadata. No other
+metadata is covered. The signature is stored base64 encoded
+in the messages.METADATA_SIGNATURE metadata of a copy of the
+message, with the key ID in the messages.METADATA_SIGNATURE_KEY_ID
+metadata, and the copy is written to the output.
+
+The serialization is the JSON encoding of those values, decoded
+and encoded again, so that structs and maps with the same
+entries serialize alike, with object keys in sorted order, and
+a header rebuilt as a map on the other side of a process
+boundary still verifies. Byte slices, which JSON encodes as
+base64 strings, are listed by where they are in the values, so
+that a byte slice and its base64 string sign differently.
+Values with data JSON leaves out, such as unexported or "-"
+tagged struct fields, are refused rather than signed without it. Messages that cannot be signed, because
+the key is unknown, the message has no metadata or its header
+or body cannot be encoded, are not written.
+
+All other messages are written through unchanged.
+*/
+type Signer struct {
+	Pipe
+	Name  string
+	Keys  KeyProvider // Source of the signing keys
+	KeyID string      // ID of the key to sign with
+
+	signed int
+	failed int
+	mutex  sync.Mutex
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are signed, then written to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if the message could not be signed or
+the write to the output failed.
+*/
+func (self *Signer) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Sign a copy of normal messages
+		signed := messages.Copy(message)
+		if self.Sign(signed) == nil {
+			success = self.Output.Write(signed)
+		} else {
+			success = false
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Signer", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+/*
+Sign a message with the current key, storing the signature in its metadata.
+
+- parameter message: the IPipeMessage to sign
+
+- returns: an error if the message could not be signed.
+*/
+func (self *Signer) Sign(message interfaces.IPipeMessage) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	err := self.sign(message)
+	if err != nil {
+		self.failed++
+	} else {
+		self.signed++
+	}
+	return err
+}
+
+/*
+Rotate Sign subsequent messages with another key.
+
+- parameter keyID: the ID of the key to sign with
+*/
+func (self *Signer) Rotate(keyID string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.KeyID = keyID
+}
+
+/*
+Status Get the ID of the signing key, and the number of messages signed and failed.
+*/
+func (self *Signer) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return map[string]interface{}{"keyID": self.KeyID, "signed": self.signed, "failed": self.failed}
+}
+
+// sign Store the signature and key ID in the metadata of a message. Caller holds the mutex.
+func (self *Signer) sign(message interfaces.IPipeMessage) error {
+	if self.Keys == nil {
+		return errors.New("signer: no keys")
+	}
+	key, ok := self.Keys.Key(self.KeyID)
+	if !ok {
+		return errors.New("signer: unknown key ID " + self.KeyID)
+	}
+	signature, err := signMessage(message, self.KeyID, key)
+	if err != nil {
+		return err
+	}
+	if !messages.SetMetadata(message, messages.METADATA_SIGNATURE_KEY_ID, self.KeyID) ||
+		!messages.SetMetadata(message, messages.METADATA_SIGNATURE, base64.StdEncoding.EncodeToString(signature)) {
+		return errors.New("signer: message has no metadata")
+	}
+	return nil
+}
+
+// signMessage Compute the HMAC of the canonical serialization of a message.
+func signMessage(message interfaces.IPipeMessage, keyID string, key []byte) ([]byte, error) {
+	serialized, err := canonical(keyID, message.Type(), message.Header(), message.Body(), message.Priority(),
+		messages.Metadata(message, messages.METADATA_SENDER))
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(serialized)
+	return mac.Sum(nil), nil
+}
+
+// canonical Serialize values as JSON with sorted object keys, however they are typed, with the paths of their byte slices, refusing data JSON leaves out.
+func canonical(values ...interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	if incomplete(reflect.ValueOf(values)) {
+		return nil, errors.New("signer: value has fields JSON does not encode")
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	byteSlices(reflect.ValueOf(values), "", &paths)
+	sort.Strings(paths)
+	return json.Marshal([]interface{}{generic, paths})
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encodesItself Does the type marshal itself, e.g. time.Time?
+func encodesItself(_type reflect.Type) bool {
+	return _type.Implements(jsonMarshaler) || _type.Implements(textMarshaler) ||
+		reflect.PointerTo(_type).Implements(jsonMarshaler) || reflect.PointerTo(_type).Implements(textMarshaler)
+}
+
+// incomplete Does the value hold data that its JSON encoding leaves out, such as unexported struct fields?
+func incomplete(value reflect.Value) bool {
+	if !value.IsValid() || encodesItself(value.Type()) {
+		return false
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return !value.IsNil() && incomplete(value.Elem())
+	case reflect.Slice, reflect.Array:
+		for index := 0; index < value.Len(); index++ {
+			if incomplete(value.Index(index)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iterator := value.MapRange()
+		for iterator.Next() {
+			if incomplete(iterator.Value()) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for index := 0; index < value.NumField(); index++ {
+			field := value.Type().Field(index)
+			if field.Tag.Get("json") == "-" || !field.IsExported() && !(field.Anonymous && indirect(field.Type).Kind() == reflect.Struct) {
+				return true // left out of the encoding, unlike the exported fields of embedded structs
+			}
+			if incomplete(value.Field(index)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// byteSlices Collect the paths of the non-empty byte slices in a value, as the JSON keys and indexes leading to them.
+func byteSlices(value reflect.Value, path string, paths *[]string) {
+	if !value.IsValid() || encodesItself(value.Type()) {
+		return
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			byteSlices(value.Elem(), path, paths)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 && !encodesItself(value.Type().Elem()) {
+			if value.Len() > 0 { // encoded as a base64 string
+				*paths = append(*paths, path)
+			}
+			return
+		}
+		for index := 0; index < value.Len(); index++ {
+			byteSlices(value.Index(index), path+"/"+strconv.Itoa(index), paths)
+		}
+	case reflect.Map:
+		iterator := value.MapRange()
+		for iterator.Next() {
+			byteSlices(iterator.Value(), path+"/"+pathKey(jsonKey(iterator.Key())), paths)
+		}
+	case reflect.Struct:
+		for index := 0; index < value.NumField(); index++ {
+			field := value.Type().Field(index)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+				byteSlices(value.Field(index), path, paths) // fields promoted into the object
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			byteSlices(value.Field(index), path+"/"+pathKey(name), paths)
+		}
+	}
+}
+
+// jsonKey Get the object key JSON encodes a map key as.
+func jsonKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, _ := marshaler.MarshalText()
+		return string(text)
+	}
+	return fmt.Sprint(key.Interface())
+}
+
+// pathKey Quote an object key for a path, so that keys containing "/" cannot be mistaken for several.
+func pathKey(key string) string {
+	quoted, _ := json.Marshal(key)
+	return string(quoted)
+}
+
+// indirect Get the type a pointer type points to, or the type itself.
+func indirect(_type reflect.Type) reflect.Type {
+	if _type.Kind() == reflect.Pointer {
+		return _type.Elem()
+	}
+	return _type
+}
@@ -0,0 +1,49 @@
+//
+//  Splitter.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+)
+
+/*
+Splitter Pipe Splitter.
+
+Expands batch messages written by an Aggregator back into
+individual messages. A normal message whose body is a
+[]interfaces.IPipeMessage has each message of its body written
+to the output in order. All other messages are written through
+unchanged.
+*/
+type Splitter struct {
+	Pipe
+}
+
+/*
+Write Handle the incoming message.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if writing any of the messages to the output failed.
+*/
+func (self *Splitter) Write(message interfaces.IPipeMessage) bool {
+	batch, ok := message.Body().([]interfaces.IPipeMessage)
+	if message.Type() != messages.NORMAL || !ok {
+		return self.Output.Write(message)
+	}
+
+	success := true
+	for _, item := range batch {
+		if self.Output.Write(item) == false {
+			success = false
+		}
+	}
+	return success
+}
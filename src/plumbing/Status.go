@@ -0,0 +1,26 @@
+//
+//  Status.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+)
+
+// reportStatus Append the status of a fitting to a status query if it is addressed to the fitting, false if the query is malformed.
+func reportStatus(message interfaces.IPipeMessage, name string, fitting string, status func() map[string]interface{}) bool {
+	query, ok := message.(*messages.StatusMessage)
+	if !ok {
+		return false
+	}
+	if query.IsAddressedTo(name) {
+		query.AddRecord(messages.StatusRecord{Name: name, Fitting: fitting, State: status()})
+	}
+	return true
+}
@@ -8,16 +8,51 @@
 
 package plumbing
 
-import "github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+)
 
 /*
 TeeMerge Merging Pipe Tee.
 
 Writes the messages from multiple input pipelines into
 a single output pipe fitting.
+
+Each connected input is tracked, and may be given a label.
+If StampSource is set, messages from a labeled input are
+copied with messages.Copy, and the label is stamped into the
+messages.METADATA_SOURCE metadata of the copy, so that a
+message shared with other branches keeps its own source.
+
+By default messages are written to the output synchronously,
+in the writer's goroutine. In Async mode each input has its own
+buffer of up to Backlog messages, and a single goroutine writes
+the buffered messages to the output taking one from each input
+in turn, so that a chatty input cannot starve the others. A
+writer whose buffer is full waits for room.
 */
 type TeeMerge struct {
 	Pipe
+	StampSource bool // Stamp the input label into the metadata of merged messages
+	Async       bool // Buffer inputs and interleave them fairly
+	Backlog     int  // Messages buffered per input in Async mode, at least 1
+
+	inputs      []*mergeInput
+	cursor      int
+	draining    bool
+	inputsMutex sync.Mutex
+	inputsCond  *sync.Cond
+}
+
+// mergeInput The fitting an input is connected to, which knows the input it serves.
+type mergeInput struct {
+	merge    *TeeMerge
+	input    interfaces.IPipeFitting
+	label    string
+	buffered []interfaces.IPipeMessage
+	removed  bool
 }
 
 /*
@@ -29,5 +64,180 @@ by calling this method repeatedly.
 - parameter input: the IPipeFitting to connect for input.
 */
 func (self *TeeMerge) ConnectInput(input interfaces.IPipeFitting) bool {
-	return input.Connect(self)
+	return self.ConnectLabeledInput(input, "")
+}
+
+/*
+ConnectLabeledInput  Connect an input IPipeFitting with a source label.
+
+- parameter input: the IPipeFitting to connect for input.
+
+- parameter label: the label stamped on messages from this input if StampSource is set.
+*/
+func (self *TeeMerge) ConnectLabeledInput(input interfaces.IPipeFitting, label string) bool {
+	adapter := &mergeInput{merge: self, input: input, label: label}
+	if !input.Connect(adapter) {
+		return false
+	}
+
+	self.inputsMutex.Lock()
+	defer self.inputsMutex.Unlock()
+
+	self.inputs = append(self.inputs, adapter)
+	return true
+}
+
+/*
+DisconnectInput  Disconnect an input IPipeFitting.
+
+Messages still buffered for the input in Async mode are discarded.
+
+- parameter input: the IPipeFitting to disconnect.
+
+- returns: IPipeFitting the disconnected input, or nil if it was not connected.
+*/
+func (self *TeeMerge) DisconnectInput(input interfaces.IPipeFitting) interfaces.IPipeFitting {
+	adapter := self.removeInput(input)
+	if adapter == nil {
+		return nil
+	}
+	if tee, ok := input.(interface {
+		DisconnectFitting(target interfaces.IPipeFitting) interfaces.IPipeFitting
+	}); ok { // inputs with several outputs, like a TeeSplit
+		tee.DisconnectFitting(adapter)
+	} else {
+		input.Disconnect()
+	}
+	return input
+}
+
+// removeInput Stop tracking an input, returning the fitting it was connected to, or nil if it was not tracked.
+func (self *TeeMerge) removeInput(input interfaces.IPipeFitting) *mergeInput {
+	self.inputsMutex.Lock()
+	defer self.inputsMutex.Unlock()
+
+	for index, adapter := range self.inputs {
+		if adapter.input == input {
+			self.inputs = append(self.inputs[:index], self.inputs[index+1:]...)
+			if self.cursor > index {
+				self.cursor--
+			}
+			adapter.removed = true
+			adapter.buffered = nil
+			self.cond().Broadcast()
+			return adapter
+		}
+	}
+	return nil
+}
+
+/*
+Inputs  Get the connected inputs, in the order they were connected.
+*/
+func (self *TeeMerge) Inputs() []interfaces.IPipeFitting {
+	self.inputsMutex.Lock()
+	defer self.inputsMutex.Unlock()
+
+	inputs := make([]interfaces.IPipeFitting, len(self.inputs))
+	for index, adapter := range self.inputs {
+		inputs[index] = adapter.input
+	}
+	return inputs
+}
+
+// enqueue Buffer a message from an input, waiting for room, and make sure it is drained.
+func (self *TeeMerge) enqueue(adapter *mergeInput, message interfaces.IPipeMessage) bool {
+	self.inputsMutex.Lock()
+	defer self.inputsMutex.Unlock()
+
+	for !adapter.removed && len(adapter.buffered) >= self.backlog() {
+		self.cond().Wait()
+	}
+	if adapter.removed {
+		return false
+	}
+	adapter.buffered = append(adapter.buffered, message)
+	if !self.draining {
+		self.draining = true
+		go self.drain()
+	}
+	return true
+}
+
+// drain Write buffered messages to the output, one input at a time in turn, until all buffers are empty.
+func (self *TeeMerge) drain() {
+	for {
+		self.inputsMutex.Lock()
+		message := self.next()
+		if message == nil {
+			self.draining = false
+			self.inputsMutex.Unlock()
+			return
+		}
+		self.cond().Broadcast()
+		self.inputsMutex.Unlock()
+
+		self.Output.Write(message)
+	}
+}
+
+// next Take the next message in round-robin order, or nil if all buffers are empty. Caller holds inputsMutex.
+func (self *TeeMerge) next() interfaces.IPipeMessage {
+	for range self.inputs {
+		if self.cursor >= len(self.inputs) {
+			self.cursor = 0
+		}
+		adapter := self.inputs[self.cursor]
+		self.cursor++
+		if len(adapter.buffered) > 0 {
+			message := adapter.buffered[0]
+			adapter.buffered = adapter.buffered[1:]
+			return message
+		}
+	}
+	return nil
+}
+
+// cond Get the condition writers wait on for buffer room. Caller holds inputsMutex.
+func (self *TeeMerge) cond() *sync.Cond {
+	if self.inputsCond == nil {
+		self.inputsCond = sync.NewCond(&self.inputsMutex)
+	}
+	return self.inputsCond
+}
+
+// backlog Get the per-input buffer size, at least 1.
+func (self *TeeMerge) backlog() int {
+	if self.Backlog < 1 {
+		return 1
+	}
+	return self.Backlog
+}
+
+/*
+Connect Can't connect anything to an input's side of the merge.
+*/
+func (self *mergeInput) Connect(output interfaces.IPipeFitting) bool {
+	return false
+}
+
+/*
+Disconnect Can't disconnect since you can't connect, either.
+*/
+func (self *mergeInput) Disconnect() interfaces.IPipeFitting {
+	return nil
+}
+
+/*
+Write a message from the input into the merge.
+*/
+func (self *mergeInput) Write(message interfaces.IPipeMessage) bool {
+	if self.merge.StampSource && self.label != "" {
+		message = messages.Copy(message)
+		messages.SetMetadata(message, messages.METADATA_SOURCE, self.label)
+	}
+	if self.merge.Async {
+		return self.merge.enqueue(self, message)
+	}
+	return self.merge.Output.Write(message)
 }
@@ -51,6 +51,9 @@ func (self *TeeSplit) Disconnect() interfaces.IPipeFitting {
 	self.outputsMutex.Lock()
 	defer self.outputsMutex.Unlock()
 
+	if len(self.outputs) == 0 {
+		return nil
+	}
 	disconnectedFitting := self.outputs[len(self.outputs)-1]
 	self.outputs = self.outputs[:len(self.outputs)-1]
 	return disconnectedFitting
@@ -0,0 +1,346 @@
+//
+//  Throttle.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	THROTTLE_DROP  = "drop"  // Excess messages are dropped and the write fails (default behavior)
+	THROTTLE_DELAY = "delay" // Excess messages block the writer until they may pass
+	THROTTLE_QUEUE = "queue" // Excess messages are stored and written out as the rate allows
+)
+
+/*
+Throttle Pipe Throttle.
+
+Limits the rate at which normal messages are written to the
+output using a token bucket: up to Burst messages may pass at
+once, and the bucket refills at Rate messages per second. A
+Rate of zero or less disables throttling.
+
+What happens to messages in excess of the rate depends on the
+Mode: THROTTLE_DROP drops them, THROTTLE_DELAY blocks the
+writer until the message may pass, and THROTTLE_QUEUE stores
+them and writes them out in order as the rate allows.
+
+If a Key function is set, each key it returns gets its own
+bucket, so that one chatty source does not use up the rate of
+the others. Keys whose bucket has refilled and which hold no
+messages are dropped as new keys arrive, since a new bucket
+starts full anyway.
+
+Messages are written to the output outside the lock of the
+throttle, so a slow output only holds up its own writer.
+
+The rate and burst can be changed at runtime with a
+ThrottleControlMessage addressed to the Name of the throttle.
+All other control messages are written through unthrottled.
+*/
+type Throttle struct {
+	Pipe
+	Name  string
+	Rate  float64                                      // Messages per second, 0 for no limit
+	Burst int                                          // Messages that may pass at once, at least 1
+	Mode  string                                       // THROTTLE_DROP, THROTTLE_DELAY or THROTTLE_QUEUE
+	Key   func(message interfaces.IPipeMessage) string // Optional per-key limits, nil for a single bucket
+	Clock Clock                                        // Time source, nil for the system clock
+
+	keys  map[string]*throttleKey
+	swept int // Number of keys after the last sweep for idle keys
+	mutex sync.Mutex
+}
+
+// throttleKey The token bucket and stored messages of a key.
+type throttleKey struct {
+	tokens    float64
+	last      time.Time
+	queue     []interfaces.IPipeMessage
+	timer     Timer
+	releasing bool // Stored messages are being written out
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are throttled according to the Mode.
+
+The messages.SET_RATE message type tells the Throttle to
+take the rate and burst from the ThrottleControlMessage if
+it is addressed to this throttle. Otherwise, it is written
+through to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if the message was dropped or
+subsequent operations in the pipeline failed.
+*/
+func (self *Throttle) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Throttle normal messages
+		key := ""
+		if self.Key != nil {
+			key = self.Key(message)
+		}
+		switch self.Mode {
+		case THROTTLE_DELAY:
+			if wait := self.reserve(key); wait > 0 {
+				self.sleep(wait)
+			}
+			success = self.Output.Write(message)
+		case THROTTLE_QUEUE:
+			success = self.enqueue(key, message)
+		default:
+			if self.take(key) {
+				success = self.Output.Write(message)
+			} else {
+				success = false
+			}
+		}
+	case messages.SET_RATE: // Accept rate and burst from control message
+		if control, ok := message.(*messages.ThrottleControlMessage); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			self.SetRate(control.Rate(), control.Burst())
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Throttle", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+// IsTarget Is the message directed at this throttle instance?
+func (self *Throttle) IsTarget(message interfaces.IPipeMessage) bool {
+	control, ok := message.(*messages.ThrottleControlMessage)
+	return ok && control.Name() == self.Name
+}
+
+/*
+SetRate Change the rate and burst of every key.
+
+Stored messages are rescheduled according to the new rate.
+
+- parameter rate: messages per second, 0 for no limit
+
+- parameter burst: messages that may pass at once
+*/
+func (self *Throttle) SetRate(rate float64, burst int) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	now := clockOrSystem(self.Clock).Now()
+	for _, state := range self.keys {
+		self.refill(state, now)
+	}
+	self.Rate = rate
+	self.Burst = burst
+	for key, state := range self.keys {
+		state.tokens = math.Min(state.tokens, float64(self.burst()))
+		if state.timer != nil {
+			state.timer.Stop()
+			state.timer = nil
+			self.schedule(key, state)
+		}
+	}
+}
+
+// reserve Take a token from the bucket of the key, returning how long to wait until it is due.
+func (self *Throttle) reserve(key string) time.Duration {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.Rate <= 0 {
+		return 0
+	}
+	state := self.state(key)
+	state.tokens--
+	if state.tokens >= 0 {
+		return 0
+	}
+	return self.duration(-state.tokens)
+}
+
+// sleep Block for the given duration on the clock.
+func (self *Throttle) sleep(duration time.Duration) {
+	done := make(chan struct{})
+	clockOrSystem(self.Clock).AfterFunc(duration, func() { close(done) })
+	<-done
+}
+
+// enqueue Write the message if the key has a token and nothing stored, otherwise store it.
+func (self *Throttle) enqueue(key string, message interfaces.IPipeMessage) bool {
+	self.mutex.Lock()
+	if self.Rate <= 0 && self.keys[key] == nil {
+		self.mutex.Unlock()
+		return self.Output.Write(message)
+	}
+	state := self.state(key)
+	if len(state.queue) == 0 && !state.releasing && self.takeToken(state) {
+		self.mutex.Unlock()
+		return self.Output.Write(message)
+	}
+	state.queue = append(state.queue, message)
+	if !state.releasing {
+		self.schedule(key, state)
+	}
+	self.mutex.Unlock()
+	return true
+}
+
+// release Write out as many stored messages of the key as its tokens allow.
+func (self *Throttle) release(key string) {
+	self.mutex.Lock()
+	state := self.keys[key]
+	if state == nil || state.releasing {
+		self.mutex.Unlock()
+		return
+	}
+	state.timer = nil
+	var due []interfaces.IPipeMessage
+	for len(state.queue) > 0 && self.takeToken(state) {
+		due = append(due, state.queue[0])
+		state.queue = state.queue[1:]
+	}
+	state.releasing = true
+	self.mutex.Unlock()
+
+	for _, message := range due {
+		self.Output.Write(message)
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	state.releasing = false
+	if len(state.queue) > 0 {
+		self.schedule(key, state)
+	} else {
+		state.queue = nil
+	}
+}
+
+// schedule Arrange for stored messages of the key to be released when a token is due. Caller holds the mutex.
+func (self *Throttle) schedule(key string, state *throttleKey) {
+	if state.timer != nil {
+		return
+	}
+	var wait time.Duration
+	if self.Rate > 0 {
+		self.refill(state, clockOrSystem(self.Clock).Now())
+		if state.tokens < 1 {
+			wait = self.duration(1 - state.tokens)
+		}
+	}
+	state.timer = clockOrSystem(self.Clock).AfterFunc(wait, func() { self.release(key) })
+}
+
+// takeToken Take a token from the bucket of the key if one is available. Caller holds the mutex.
+func (self *Throttle) takeToken(state *throttleKey) bool {
+	if self.Rate <= 0 {
+		return true
+	}
+	self.refill(state, clockOrSystem(self.Clock).Now())
+	if state.tokens >= 1 {
+		state.tokens--
+		return true
+	}
+	return false
+}
+
+// take Take a token from the bucket of the key if one is available.
+func (self *Throttle) take(key string) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.Rate <= 0 {
+		return true
+	}
+	return self.takeToken(self.state(key))
+}
+
+// state Get the refilled state of the key, creating it with a full bucket if needed. Caller holds the mutex.
+func (self *Throttle) state(key string) *throttleKey {
+	now := clockOrSystem(self.Clock).Now()
+	if self.keys == nil {
+		self.keys = make(map[string]*throttleKey)
+	}
+	state, ok := self.keys[key]
+	if !ok {
+		if len(self.keys) >= 2*self.swept {
+			self.sweep(now)
+		}
+		state = &throttleKey{tokens: float64(self.burst()), last: now}
+		self.keys[key] = state
+	}
+	self.refill(state, now)
+	return state
+}
+
+// sweep Drop the keys whose bucket is full and which hold no messages. Caller holds the mutex.
+func (self *Throttle) sweep(now time.Time) {
+	for key, state := range self.keys {
+		self.refill(state, now)
+		if len(state.queue) == 0 && state.timer == nil && !state.releasing &&
+			(self.Rate <= 0 || state.tokens >= float64(self.burst())) {
+			delete(self.keys, key)
+		}
+	}
+	self.swept = len(self.keys)
+}
+
+// refill Add the tokens accumulated since the bucket was last refilled. Caller holds the mutex.
+func (self *Throttle) refill(state *throttleKey, now time.Time) {
+	if self.Rate > 0 && now.After(state.last) {
+		state.tokens = math.Min(float64(self.burst()), state.tokens+now.Sub(state.last).Seconds()*self.Rate)
+	}
+	state.last = now
+}
+
+// burst Get the bucket capacity, at least 1.
+func (self *Throttle) burst() int {
+	if self.Burst < 1 {
+		return 1
+	}
+	return self.Burst
+}
+
+// duration Get the time it takes to accumulate the given number of tokens.
+func (self *Throttle) duration(tokens float64) time.Duration {
+	return time.Duration(math.Round(tokens / self.Rate * float64(time.Second)))
+}
+
+/*
+Status Get the rate, burst and mode of the throttle, the number of keys it tracks, and the number of messages it holds in THROTTLE_QUEUE mode.
+*/
+func (self *Throttle) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	queued := 0
+	for _, state := range self.keys {
+		queued += len(state.queue)
+	}
+	return map[string]interface{}{"rate": self.Rate, "burst": self.Burst, "mode": self.Mode, "queued": queued, "keys": len(self.keys)}
+}
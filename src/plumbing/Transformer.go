@@ -0,0 +1,146 @@
+//
+//  Transformer.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+)
+
+/*
+Transformer Pipe Transformer.
+
+Transformers rewrite normal messages before writing them to
+their output pipe fitting. The Transform function maps each
+message to a single message, or to nil to drop it. If the
+Expand function is set it is used instead, mapping each message
+to any number of messages which are written out in order.
+
+Like a Filter, a Transformer may have its parameters and
+functions passed to it by control message, as well as having its
+Bypass/Transform operation mode toggled via control message.
+*/
+type Transformer struct {
+	Pipe
+	Name      string
+	Transform func(message interfaces.IPipeMessage, params interface{}) (interfaces.IPipeMessage, error)
+	Expand    func(message interfaces.IPipeMessage, params interface{}) ([]interfaces.IPipeMessage, error)
+	Params    interface{}
+	Mode      string
+}
+
+/*
+Write Handle the incoming message.
+
+If message type is normal, transform the message (unless in
+BYPASS_TRANSFORM mode) and write the results to the output pipe
+fitting. If the transform returns an error, nothing is written.
+
+The messages.SET_TRANSFORM_PARAMS message type tells the
+Transformer to retrieve the parameters object, and the
+messages.SET_TRANSFORM message type the transform and expand
+functions, from the TransformerControlMessage.
+
+The messages.BYPASS_TRANSFORM and messages.TRANSFORM message
+types toggle between passing normal messages through unchanged
+and transforming them, which is the default mode of operation.
+
+The Transformer only acts on the control message if it is
+targeted to this named transformer instance. Otherwise, it
+writes through to the output.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean True if the transform did not return an error and
+subsequent operations in the pipeline succeed.
+*/
+func (self *Transformer) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Transform normal messages
+		if self.Mode == messages.BYPASS_TRANSFORM {
+			success = self.Output.Write(message)
+		} else if results, err := self.ApplyTransform(message); err != nil {
+			success = false
+		} else {
+			for _, result := range results {
+				if self.Output.Write(result) == false {
+					success = false
+				}
+			}
+		}
+	case messages.SET_TRANSFORM_PARAMS: // Accept parameters from control message
+		if control, ok := message.(*messages.TransformerControlMessage); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			self.Params = control.Params()
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.SET_TRANSFORM: // Accept transform functions from control message
+		if control, ok := message.(*messages.TransformerControlMessage); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			self.Transform = control.Transform()
+			self.Expand = control.Expand()
+		} else {
+			success = self.Output.Write(message)
+		}
+		// Toggle between Transform or Bypass operational modes
+	case messages.BYPASS_TRANSFORM:
+		fallthrough
+	case messages.TRANSFORM:
+		if _, ok := message.(*messages.TransformerControlMessage); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			self.Mode = message.Type()
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Transformer", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+// IsTarget Is the message directed at this transformer instance?
+func (self *Transformer) IsTarget(message interfaces.IPipeMessage) bool {
+	control, ok := message.(*messages.TransformerControlMessage)
+	return ok && control.Name() == self.Name
+}
+
+// ApplyTransform Transform the message into the messages to write out.
+func (self *Transformer) ApplyTransform(message interfaces.IPipeMessage) ([]interfaces.IPipeMessage, error) {
+	if self.Expand != nil {
+		return self.Expand(message, self.Params)
+	}
+	if self.Transform == nil {
+		return []interfaces.IPipeMessage{message}, nil
+	}
+	result, err := self.Transform(message, self.Params)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return []interfaces.IPipeMessage{result}, nil
+}
+
+/*
+Status Get the mode and parameters of the transformer.
+*/
+func (self *Transformer) Status() map[string]interface{} {
+	return map[string]interface{}{"mode": self.Mode, "params": self.Params}
+}
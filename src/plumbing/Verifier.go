@@ -0,0 +1,139 @@
+//
+//  Verifier.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sync"
+)
+
+/*
+Verifier Pipe Verifier.
+
+Checks the signatures of normal messages signed by a Signer,
+writing those with a valid signature to the output. The
+signature is checked with the key whose ID the message
+carries, so a Verifier given both the old and the new keys
+accepts messages across a key rotation.
+
+Messages with a missing or bad signature, or signed with a
+key that is not in Keys, are rejected: they are written to
+the Quarantine fitting if there is one, otherwise the write
+fails.
+
+All other messages are written through unchanged.
+*/
+type Verifier struct {
+	Pipe
+	Name       string
+	Keys       KeyProvider             // Source of the keys messages may be signed with
+	Quarantine interfaces.IPipeFitting // Optional fitting to divert rejected messages to
+
+	verified int
+	rejected int
+	mutex    sync.Mutex
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages with a valid signature are written to the
+output, others to the Quarantine fitting if any.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean true if the message was written successfully
+to the output, or to the quarantine if rejected.
+*/
+func (self *Verifier) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Verify normal messages
+		if self.Verify(message) == nil {
+			success = self.Output.Write(message)
+		} else if self.Quarantine != nil {
+			success = self.Quarantine.Write(message)
+		} else {
+			success = false
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Verifier", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+/*
+Verify the signature of a message.
+
+- parameter message: the IPipeMessage to verify
+
+- returns: an error if the signature is missing or bad, or nil if it is valid.
+*/
+func (self *Verifier) Verify(message interfaces.IPipeMessage) error {
+	err := self.verify(message)
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if err != nil {
+		self.rejected++
+	} else {
+		self.verified++
+	}
+	return err
+}
+
+/*
+Status Get the number of messages verified and rejected.
+*/
+func (self *Verifier) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return map[string]interface{}{"verified": self.verified, "rejected": self.rejected}
+}
+
+// verify Check the signature of a message against the key it names.
+func (self *Verifier) verify(message interfaces.IPipeMessage) error {
+	encoded, ok := messages.Metadata(message, messages.METADATA_SIGNATURE).(string)
+	if !ok {
+		return errors.New("verifier: message is not signed")
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.New("verifier: malformed signature")
+	}
+	keyID, _ := messages.Metadata(message, messages.METADATA_SIGNATURE_KEY_ID).(string)
+	if self.Keys == nil {
+		return errors.New("verifier: no keys")
+	}
+	key, ok := self.Keys.Key(keyID)
+	if !ok {
+		return errors.New("verifier: unknown key ID " + keyID)
+	}
+	expected, err := signMessage(message, keyID, key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(signature, expected) {
+		return errors.New("verifier: bad signature")
+	}
+	return nil
+}
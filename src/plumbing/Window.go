@@ -0,0 +1,356 @@
+//
+//  Window.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	WINDOW_TUMBLING = "tumbling" // Consecutive, non-overlapping windows of Size (default behavior)
+	WINDOW_SLIDING  = "sliding"  // Windows of Size starting every Slide, which may overlap
+	WINDOW_SESSION  = "session"  // Windows spanning bursts of messages separated by at least Gap
+)
+
+/*
+Window Pipe Window.
+
+Groups normal messages into windows of time and writes one
+result message per window when it closes, produced by the
+Reduce function from the messages in the window. Without a
+Reduce function the result is a normal message whose body is
+the []interfaces.IPipeMessage of the window, like an Aggregator
+batch.
+
+WINDOW_TUMBLING windows are consecutive intervals of Size,
+WINDOW_SLIDING windows are intervals of Size starting every
+Slide, so a message may fall into several, and WINDOW_SESSION
+windows last from a message until no message has arrived for
+Gap. Tumbling and sliding windows are aligned to multiples of
+their Size and Slide.
+
+By default windows are in processing time: a message falls in
+the window of the time it is written, and windows close as the
+Clock passes their end. With EventTime set, a message falls in
+the window of its timestamp, given by the Timestamp function
+or taken from the messages.METADATA_TIMESTAMP metadata, and
+windows close once a message with a timestamp past their end
+arrives. Messages for windows that have already closed are
+dropped.
+
+The kind and parameters of the windows can be changed at
+runtime with a WindowControlMessage addressed to the Name of
+the window, which closes the open windows first. A
+messages.FLUSH control message closes all open windows, then
+is written through. All other control messages are written
+through unchanged.
+*/
+type Window struct {
+	Pipe
+	Name      string
+	Kind      string                                                  // WINDOW_TUMBLING, WINDOW_SLIDING or WINDOW_SESSION
+	Size      time.Duration                                           // Length of tumbling and sliding windows
+	Slide     time.Duration                                           // Interval between the starts of sliding windows
+	Gap       time.Duration                                           // Inactivity that closes a session window
+	EventTime bool                                                    // Window by message timestamp instead of processing time
+	Timestamp func(message interfaces.IPipeMessage) (time.Time, bool) // Event time of a message, nil for its timestamp metadata
+	// Produce the result message of a closed window
+	Reduce func(start time.Time, end time.Time, window []interfaces.IPipeMessage) interfaces.IPipeMessage
+	Clock  Clock // Time source, nil for the system clock
+
+	windows    map[time.Time]*timeWindow
+	session    *timeWindow
+	watermark  time.Time
+	timer      Timer
+	generation int
+	mutex      sync.Mutex
+}
+
+type timeWindow struct {
+	start    time.Time
+	end      time.Time
+	messages []interfaces.IPipeMessage
+}
+
+/*
+Write Handle the incoming message.
+
+Normal messages are added to the windows they fall in, and
+any windows that have ended are closed.
+
+- parameter message: IPipeMessage to write on the output
+
+- returns: Boolean false if the message was dropped or a write
+to the output failed.
+*/
+func (self *Window) Write(message interfaces.IPipeMessage) bool {
+	success := true
+
+	switch message.Type() {
+	case messages.NORMAL: // Collect normal messages
+		success = self.Collect(message)
+	case messages.SET_WINDOW: // Accept kind and parameters from control message
+		if control, ok := message.(*messages.WindowControlMessage); !ok {
+			success = false // malformed control message
+		} else if self.IsTarget(message) {
+			success = self.SetWindow(control.Kind(), control.Size(), control.Slide(), control.Gap())
+		} else {
+			success = self.Output.Write(message)
+		}
+	case messages.FLUSH: // Close open windows, then let the flush through
+		success = self.Flush()
+		if self.Output.Write(message) == false {
+			success = false
+		}
+	case messages.STATUS: // Report status, then let the query through
+		if reportStatus(message, self.Name, "Window", self.Status) {
+			success = self.Output.Write(message)
+		} else {
+			success = false // malformed status query
+		}
+	default: // Write control messages for other fittings through
+		success = self.Output.Write(message)
+	}
+
+	return success
+}
+
+// IsTarget Is the message directed at this window instance?
+func (self *Window) IsTarget(message interfaces.IPipeMessage) bool {
+	control, ok := message.(*messages.WindowControlMessage)
+	return ok && control.Name() == self.Name
+}
+
+/*
+Collect a message into the windows it falls in.
+
+- parameter message: the IPipeMessage to collect.
+
+- returns: Bool false if the message falls only in closed windows
+or a write to the output failed.
+*/
+func (self *Window) Collect(message interfaces.IPipeMessage) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	at := clockOrSystem(self.Clock).Now()
+	if self.EventTime {
+		timestamp, ok := self.timestamp(message)
+		if !ok {
+			return false
+		}
+		at = timestamp
+	}
+
+	success := true
+	if self.EventTime {
+		success = self.close(at, false)
+	}
+
+	collected := false
+	if self.Kind == WINDOW_SESSION {
+		if self.session != nil && !at.Before(self.session.end) {
+			if self.write(self.session) == false {
+				success = false
+			}
+			self.session = nil
+		}
+		if self.session == nil && !at.Before(self.watermark) {
+			self.session = &timeWindow{start: at}
+		}
+		if self.session != nil {
+			if end := at.Add(self.Gap); end.After(self.session.end) {
+				self.session.end = end
+			}
+			self.session.messages = append(self.session.messages, message)
+			collected = true
+		}
+	} else {
+		size, slide := self.Size, self.Slide
+		if self.Kind != WINDOW_SLIDING || slide <= 0 {
+			slide = size
+		}
+		if size > 0 {
+			for start := at.Truncate(slide); start.Add(size).After(at); start = start.Add(-slide) {
+				if !start.Add(size).After(self.watermark) {
+					continue // already closed
+				}
+				if self.windows == nil {
+					self.windows = make(map[time.Time]*timeWindow)
+				}
+				window, ok := self.windows[start]
+				if !ok {
+					window = &timeWindow{start: start, end: start.Add(size)}
+					self.windows[start] = window
+				}
+				window.messages = append(window.messages, message)
+				collected = true
+			}
+		}
+	}
+
+	if !self.EventTime {
+		self.schedule()
+	}
+	return success && collected
+}
+
+/*
+SetWindow Close the open windows and change the kind and parameters of the windows.
+
+- parameter kind: WINDOW_TUMBLING, WINDOW_SLIDING or WINDOW_SESSION
+
+- parameter size: length of tumbling and sliding windows
+
+- parameter slide: interval between the starts of sliding windows
+
+- parameter gap: inactivity that closes a session window
+
+- returns: Bool true if the results of all closed windows were written successfully.
+*/
+func (self *Window) SetWindow(kind string, size time.Duration, slide time.Duration, gap time.Duration) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	success := self.close(time.Time{}, true)
+	self.Kind = kind
+	self.Size = size
+	self.Slide = slide
+	self.Gap = gap
+	return success
+}
+
+/*
+Flush Close all open windows.
+
+- returns: Bool true if the results of all closed windows were written successfully.
+*/
+func (self *Window) Flush() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.close(time.Time{}, true)
+}
+
+// tick Close the windows that have ended in processing time.
+func (self *Window) tick(generation int) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if generation != self.generation {
+		return
+	}
+	self.timer = nil
+	self.close(clockOrSystem(self.Clock).Now(), false)
+	self.schedule()
+}
+
+// close Write out the windows that end at or before now, or all of them, in order of their end. Caller holds the mutex.
+func (self *Window) close(now time.Time, all bool) bool {
+	var closed []*timeWindow
+	for start, window := range self.windows {
+		if all || !window.end.After(now) {
+			closed = append(closed, window)
+			delete(self.windows, start)
+		}
+	}
+	if self.session != nil && (all || !self.session.end.After(now)) {
+		closed = append(closed, self.session)
+		self.session = nil
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		if closed[i].end.Equal(closed[j].end) {
+			return closed[i].start.Before(closed[j].start)
+		}
+		return closed[i].end.Before(closed[j].end)
+	})
+
+	if now.After(self.watermark) {
+		self.watermark = now
+	}
+	if all && self.timer != nil {
+		self.timer.Stop()
+		self.timer = nil
+		self.generation++
+	}
+
+	success := true
+	for _, window := range closed {
+		if self.write(window) == false {
+			success = false
+		}
+	}
+	return success
+}
+
+// schedule Arm the timer for the earliest end of an open window in processing time. Caller holds the mutex.
+func (self *Window) schedule() {
+	var earliest time.Time
+	for _, window := range self.windows {
+		if earliest.IsZero() || window.end.Before(earliest) {
+			earliest = window.end
+		}
+	}
+	if self.session != nil && (earliest.IsZero() || self.session.end.Before(earliest)) {
+		earliest = self.session.end
+	}
+
+	if self.timer != nil {
+		self.timer.Stop()
+		self.timer = nil
+	}
+	self.generation++
+	if earliest.IsZero() {
+		return
+	}
+	clock := clockOrSystem(self.Clock)
+	generation := self.generation
+	self.timer = clock.AfterFunc(earliest.Sub(clock.Now()), func() { self.tick(generation) })
+}
+
+// write Write the result of a closed window to the output. Caller holds the mutex.
+func (self *Window) write(window *timeWindow) bool {
+	var result interfaces.IPipeMessage
+	if self.Reduce != nil {
+		result = self.Reduce(window.start, window.end, window.messages)
+	} else {
+		result = messages.NewMessage(messages.NORMAL, nil, window.messages, messages.PRIORITY_MED)
+	}
+	if result == nil {
+		return true
+	}
+	return self.Output.Write(result)
+}
+
+// timestamp Get the event time of a message.
+func (self *Window) timestamp(message interfaces.IPipeMessage) (time.Time, bool) {
+	if self.Timestamp != nil {
+		return self.Timestamp(message)
+	}
+	timestamp, ok := messages.Metadata(message, messages.METADATA_TIMESTAMP).(time.Time)
+	return timestamp, ok
+}
+
+/*
+Status Get the kind and parameters of the windows, and the number of windows open.
+*/
+func (self *Window) Status() map[string]interface{} {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	open := len(self.windows)
+	if self.session != nil {
+		open++
+	}
+	return map[string]interface{}{"kind": self.Kind, "size": self.Size, "slide": self.Slide, "gap": self.Gap, "open": open}
+}
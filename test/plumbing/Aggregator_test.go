@@ -0,0 +1,139 @@
+//
+//  Aggregator_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the Aggregator class.
+*/
+
+/*
+Test that a batch is written when it reaches the count threshold.
+*/
+func TestAggregatorMaxCount(t *testing.T) {
+	callback := Callback{}
+	aggregator := &plumbing.Aggregator{MaxCount: 3,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	message1 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	message2 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	message3 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	aggregator.Write(message1)
+	aggregator.Write(message2)
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting received no batch before the threshold")
+	}
+
+	written := aggregator.Write(message3)
+	if written != true {
+		t.Error("Expecting wrote batch to the output")
+	}
+	if len(callback.messagesReceived) != 1 {
+		t.Fatal("Expecting received 1 batch")
+	}
+
+	received := callback.messagesReceived[0]
+	if received.Type() != messages.NORMAL {
+		t.Error("Expecting batch is a normal message")
+	}
+	batch := received.Body().([]interfaces.IPipeMessage)
+	if len(batch) != 3 || batch[0] != message1 || batch[1] != message2 || batch[2] != message3 {
+		t.Error("Expecting batch holds the 3 messages in order")
+	}
+}
+
+/*
+Test that a batch is written when its messages reach the byte threshold.
+*/
+func TestAggregatorMaxBytes(t *testing.T) {
+	callback := Callback{}
+	aggregator := &plumbing.Aggregator{MaxBytes: 8,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	aggregator.Write(messages.NewMessage(messages.NORMAL, nil, []byte("abcd"), messages.PRIORITY_MED))
+	aggregator.Write(messages.NewMessage(messages.NORMAL, nil, "efg", messages.PRIORITY_MED))
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting received no batch at 7 bytes")
+	}
+
+	aggregator.Write(messages.NewMessage(messages.NORMAL, nil, "h", messages.PRIORITY_MED))
+	if len(callback.messagesReceived) != 1 {
+		t.Error("Expecting received 1 batch at 8 bytes")
+	}
+}
+
+/*
+Test that a batch is written when its age reaches the time threshold.
+*/
+func TestAggregatorMaxAge(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	aggregator := &plumbing.Aggregator{MaxAge: time.Second, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	aggregator.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	clock.Advance(500 * time.Millisecond)
+	aggregator.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	clock.Advance(500 * time.Millisecond)
+
+	if len(callback.messagesReceived) != 1 {
+		t.Fatal("Expecting received 1 batch")
+	}
+	if len(callback.messagesReceived[0].Body().([]interfaces.IPipeMessage)) != 2 {
+		t.Error("Expecting batch holds 2 messages")
+	}
+}
+
+/*
+Test that correlated messages are batched per key until complete.
+*/
+func TestAggregatorCorrelation(t *testing.T) {
+	callback := Callback{}
+	aggregator := &plumbing.Aggregator{
+		Correlation: func(message interfaces.IPipeMessage) string { return message.Header().(string) },
+		Complete: func(key string, batch []interfaces.IPipeMessage) bool {
+			return batch[len(batch)-1].Body() == "last"
+		},
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	aggregator.Write(messages.NewMessage(messages.NORMAL, "order-1", "first", messages.PRIORITY_MED))
+	aggregator.Write(messages.NewMessage(messages.NORMAL, "order-2", "first", messages.PRIORITY_MED))
+	aggregator.Write(messages.NewMessage(messages.NORMAL, "order-1", "last", messages.PRIORITY_MED))
+
+	if len(callback.messagesReceived) != 1 {
+		t.Fatal("Expecting received 1 batch")
+	}
+	if callback.messagesReceived[0].Header() != "order-1" {
+		t.Error("Expecting batch header == order-1")
+	}
+	if len(callback.messagesReceived[0].Body().([]interfaces.IPipeMessage)) != 2 {
+		t.Error("Expecting batch holds 2 messages")
+	}
+
+	// flush writes the incomplete batch, then the flush message
+	flush := messages.NewQueueControlMessage(messages.FLUSH)
+	aggregator.Write(flush)
+	if len(callback.messagesReceived) != 3 {
+		t.Fatal("Expecting received 3 messages")
+	}
+	if callback.messagesReceived[1].Header() != "order-2" {
+		t.Error("Expecting flushed batch header == order-2")
+	}
+	if callback.messagesReceived[2] != flush {
+		t.Error("Expecting flush message written through")
+	}
+}
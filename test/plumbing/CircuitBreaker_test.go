@@ -0,0 +1,182 @@
+//
+//  CircuitBreaker_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the CircuitBreaker class.
+*/
+
+/*
+Test that consecutive failures open the breaker, and that the
+open breaker short-circuits writes until a successful trial closes it.
+*/
+func TestCircuitBreakerConsecutiveFailures(t *testing.T) {
+	clock := NewFakeClock()
+	output := &FailingFitting{Failing: true}
+	breaker := &plumbing.CircuitBreaker{Name: "module", Pipe: plumbing.Pipe{Output: output},
+		FailureThreshold: 2, OpenTimeout: time.Second, Clock: clock}
+
+	// two failures open the breaker
+	written1 := breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	written2 := breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	if written1 != false || written2 != false {
+		t.Error("Expecting writes to failing output to fail")
+	}
+	if breaker.State() != plumbing.CIRCUIT_OPEN {
+		t.Error("Expecting breaker is open")
+	}
+
+	// open breaker does not write to the output
+	written3 := breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	if written3 != false {
+		t.Error("Expecting short-circuited write to fail")
+	}
+	if len(output.messagesReceived) != 2 {
+		t.Error("Expecting output received 2 messages")
+	}
+
+	// after the timeout, a successful trial closes the breaker
+	clock.Advance(time.Second)
+	if breaker.State() != plumbing.CIRCUIT_HALF_OPEN {
+		t.Error("Expecting breaker is half-open")
+	}
+	output.Failing = false
+	written4 := breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	if written4 != true {
+		t.Error("Expecting trial write succeeded")
+	}
+	if breaker.State() != plumbing.CIRCUIT_CLOSED {
+		t.Error("Expecting breaker is closed")
+	}
+	if len(output.messagesReceived) != 3 {
+		t.Error("Expecting output received 3 messages")
+	}
+}
+
+/*
+Test that a failing trial message reopens the breaker.
+*/
+func TestCircuitBreakerFailedTrialReopens(t *testing.T) {
+	clock := NewFakeClock()
+	output := &FailingFitting{Failing: true}
+	breaker := &plumbing.CircuitBreaker{Name: "module", Pipe: plumbing.Pipe{Output: output},
+		FailureThreshold: 1, OpenTimeout: time.Second, Clock: clock}
+
+	breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	clock.Advance(time.Second)
+	breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	if breaker.State() != plumbing.CIRCUIT_OPEN {
+		t.Error("Expecting breaker is open again")
+	}
+	if len(output.messagesReceived) != 2 {
+		t.Error("Expecting output received 2 messages")
+	}
+}
+
+/*
+Test that the failure ratio over the window opens the breaker.
+*/
+func TestCircuitBreakerFailureRatio(t *testing.T) {
+	output := &FailingFitting{}
+	breaker := &plumbing.CircuitBreaker{Name: "module", Pipe: plumbing.Pipe{Output: output},
+		FailureRatio: 0.5, WindowSize: 4, OpenTimeout: time.Minute, Clock: NewFakeClock()}
+
+	// alternate success and failure, never two consecutive failures
+	for i := 0; i < 3; i++ {
+		output.Failing = i%2 == 1
+		breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	}
+	if breaker.State() != plumbing.CIRCUIT_CLOSED {
+		t.Error("Expecting breaker is closed before the window is full")
+	}
+
+	output.Failing = true
+	breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	if breaker.State() != plumbing.CIRCUIT_OPEN {
+		t.Error("Expecting breaker is open at 50% failures")
+	}
+}
+
+/*
+Test that an open breaker diverts normal messages to the fallback.
+*/
+func TestCircuitBreakerFallback(t *testing.T) {
+	output := &FailingFitting{}
+	callback := Callback{}
+	breaker := &plumbing.CircuitBreaker{Name: "module", Pipe: plumbing.Pipe{Output: output},
+		Fallback: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}
+
+	openWritten := breaker.Write(messages.NewCircuitBreakerControlMessage(messages.BREAKER_OPEN, "module"))
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	written := breaker.Write(message)
+
+	if openWritten != true {
+		t.Error("Expecting wrote open message to breaker")
+	}
+	if written != true {
+		t.Error("Expecting wrote message to fallback")
+	}
+	if len(output.messagesReceived) != 0 {
+		t.Error("Expecting output received no messages")
+	}
+	if len(callback.messagesReceived) != 1 || callback.messagesReceived[0] != message {
+		t.Error("Expecting fallback received the message")
+	}
+}
+
+/*
+Test forcing the breaker open, closed and resetting it by control message.
+*/
+func TestCircuitBreakerControlMessages(t *testing.T) {
+	clock := NewFakeClock()
+	output := &FailingFitting{Failing: true}
+	breaker := &plumbing.CircuitBreaker{Name: "module", Pipe: plumbing.Pipe{Output: output},
+		FailureThreshold: 1, OpenTimeout: time.Second, Clock: clock}
+
+	// forced open breaker does not go half-open
+	breaker.Write(messages.NewCircuitBreakerControlMessage(messages.BREAKER_OPEN, "module"))
+	clock.Advance(time.Minute)
+	if breaker.State() != plumbing.CIRCUIT_OPEN {
+		t.Error("Expecting forced open breaker stays open")
+	}
+
+	// forced closed breaker ignores failures
+	breaker.Write(messages.NewCircuitBreakerControlMessage(messages.BREAKER_CLOSE, "module"))
+	breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	if breaker.State() != plumbing.CIRCUIT_CLOSED {
+		t.Error("Expecting forced closed breaker stays closed")
+	}
+
+	// reset returns to automatic operation
+	breaker.Write(messages.NewCircuitBreakerControlMessage(messages.BREAKER_RESET, "module"))
+	breaker.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	if breaker.State() != plumbing.CIRCUIT_OPEN {
+		t.Error("Expecting reset breaker opens on failure")
+	}
+
+	// control messages for other breakers are written through
+	other := messages.NewCircuitBreakerControlMessage(messages.BREAKER_CLOSE, "other")
+	breaker.Write(other)
+	if breaker.State() != plumbing.CIRCUIT_OPEN {
+		t.Error("Expecting breaker ignored control message for another breaker")
+	}
+	if output.messagesReceived[len(output.messagesReceived)-1] != other {
+		t.Error("Expecting other control message written through to the output")
+	}
+}
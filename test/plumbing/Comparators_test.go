@@ -0,0 +1,174 @@
+//
+//  Comparators_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the comparators.
+*/
+
+// sortedHeaders Store messages with the given int headers in a queue sorting with a comparator, and get the headers in order.
+func sortedHeaders(comparator plumbing.Comparator, stored []interfaces.IPipeMessage) []int {
+	queue := &plumbing.Queue{Comparator: comparator, Mode: messages.SORT, Pipe: plumbing.Pipe{Output: &plumbing.Pipe{}}}
+	for _, message := range stored {
+		queue.Write(message)
+	}
+	return receivedHeaders(queue.Snapshot())
+}
+
+// newTimedMessage Create a normal message with an int header, a priority and a metadata time at an offset from epoch, unless negative.
+func newTimedMessage(header int, priority int, key string, second int) interfaces.IPipeMessage {
+	message := messages.NewMessage(messages.NORMAL, header, nil, priority)
+	if second >= 0 {
+		messages.SetMetadata(message, key, epoch.Add(time.Duration(second)*time.Second))
+	}
+	return message
+}
+
+/*
+Test ordering by priority, keeping the order of messages of equal priority.
+*/
+func TestByPriority(t *testing.T) {
+	stored := []interfaces.IPipeMessage{
+		messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_LOW),
+		messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED),
+		messages.NewMessage(messages.NORMAL, 3, nil, messages.PRIORITY_HIGH),
+		messages.NewMessage(messages.NORMAL, 4, nil, messages.PRIORITY_MED),
+		messages.NewMessage(messages.NORMAL, 5, nil, messages.PRIORITY_HIGH),
+	}
+	if equalInts(sortedHeaders(plumbing.ByPriority, stored), []int{3, 5, 2, 4, 1}) != true {
+		t.Error("Expecting messages ordered by priority, then arrival")
+	}
+	if equalInts(sortedHeaders(nil, stored), []int{3, 5, 2, 4, 1}) != true {
+		t.Error("Expecting a queue without a comparator to order by priority")
+	}
+}
+
+/*
+Test ordering by deadline and by age, with messages lacking the metadata last.
+*/
+func TestByDeadlineAndAge(t *testing.T) {
+	stored := []interfaces.IPipeMessage{
+		newTimedMessage(1, messages.PRIORITY_MED, messages.METADATA_DEADLINE, 30),
+		newTimedMessage(2, messages.PRIORITY_MED, messages.METADATA_DEADLINE, -1),
+		newTimedMessage(3, messages.PRIORITY_MED, messages.METADATA_DEADLINE, 10),
+		newTimedMessage(4, messages.PRIORITY_MED, messages.METADATA_DEADLINE, 20),
+	}
+	if equalInts(sortedHeaders(plumbing.ByDeadline, stored), []int{3, 4, 1, 2}) != true {
+		t.Error("Expecting messages ordered by deadline, without a deadline last")
+	}
+
+	stored = []interfaces.IPipeMessage{
+		newTimedMessage(1, messages.PRIORITY_MED, messages.METADATA_TIMESTAMP, -1),
+		newTimedMessage(2, messages.PRIORITY_MED, messages.METADATA_TIMESTAMP, 5),
+		newTimedMessage(3, messages.PRIORITY_MED, messages.METADATA_TIMESTAMP, 1),
+	}
+	if equalInts(sortedHeaders(plumbing.ByAge, stored), []int{3, 2, 1}) != true {
+		t.Error("Expecting messages ordered oldest first, without a timestamp last")
+	}
+}
+
+/*
+Test composite and reversed orderings.
+*/
+func TestCompositeComparator(t *testing.T) {
+	stored := []interfaces.IPipeMessage{
+		newTimedMessage(1, messages.PRIORITY_LOW, messages.METADATA_TIMESTAMP, 1),
+		newTimedMessage(2, messages.PRIORITY_HIGH, messages.METADATA_TIMESTAMP, 9),
+		newTimedMessage(3, messages.PRIORITY_LOW, messages.METADATA_TIMESTAMP, 0),
+		newTimedMessage(4, messages.PRIORITY_HIGH, messages.METADATA_TIMESTAMP, 3),
+	}
+	if equalInts(sortedHeaders(plumbing.Composite(plumbing.ByPriority, plumbing.ByAge), stored), []int{4, 2, 3, 1}) != true {
+		t.Error("Expecting messages ordered by priority, then oldest first")
+	}
+	if equalInts(sortedHeaders(plumbing.Composite(plumbing.ByPriority, plumbing.Reverse(plumbing.ByAge)), stored), []int{2, 4, 1, 3}) != true {
+		t.Error("Expecting messages ordered by priority, then newest first")
+	}
+}
+
+/*
+Test ordering by a field of the header.
+*/
+func TestByField(t *testing.T) {
+	stored := []interfaces.IPipeMessage{
+		messages.NewMessage(messages.NORMAL, map[string]interface{}{"rank": 3, "id": 1}, nil, messages.PRIORITY_MED),
+		messages.NewMessage(messages.NORMAL, map[string]interface{}{"id": 2}, nil, messages.PRIORITY_MED),
+		messages.NewMessage(messages.NORMAL, map[string]interface{}{"rank": 1.5, "id": 3}, nil, messages.PRIORITY_MED),
+	}
+	byRank, err := plumbing.ByField("header.rank")
+	if err != nil {
+		t.Fatal("Expecting header.rank to be a valid path")
+	}
+	byID, _ := plumbing.ByField("header.id")
+	queue := &plumbing.Queue{Comparator: byRank, Mode: messages.SORT, Pipe: plumbing.Pipe{Output: &plumbing.Pipe{}}}
+	for _, message := range stored {
+		queue.Write(message)
+	}
+	sorted := queue.Snapshot()
+	var ids []int
+	for _, message := range sorted {
+		ids = append(ids, message.Header().(map[string]interface{})["id"].(int))
+	}
+	if equalInts(ids, []int{3, 1, 2}) != true {
+		t.Error("Expecting messages ordered by rank, without a rank last")
+	}
+	if byID(stored[0], stored[1]) >= 0 {
+		t.Error("Expecting header.id 1 before header.id 2")
+	}
+
+	if _, err := plumbing.ByField("header.rank > 1"); err == nil {
+		t.Error("Expecting an expression that is not a path to be rejected")
+	}
+	if _, err := plumbing.ByField("nowhere"); err == nil {
+		t.Error("Expecting an unknown path to be rejected")
+	}
+}
+
+/*
+Test setting the comparator of a queue with a control message.
+*/
+func TestQueueSetComparator(t *testing.T) {
+	callback := Callback{}
+	queue := &plumbing.Queue{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	queue.Write(newTimedMessage(1, messages.PRIORITY_MED, messages.METADATA_DEADLINE, 20))
+	queue.Write(newTimedMessage(2, messages.PRIORITY_MED, messages.METADATA_DEADLINE, 10))
+
+	if queue.Write(messages.NewQueueComparatorControlMessage(messages.SET_COMPARATOR, plumbing.ByDeadline)) != true {
+		t.Error("Expecting the comparator accepted")
+	}
+	if queue.Mode != messages.SORT || equalInts(receivedHeaders(queue.Snapshot()), []int{2, 1}) != true {
+		t.Error("Expecting the queue in sort mode with stored messages sorted by deadline")
+	}
+
+	queue.Write(newTimedMessage(3, messages.PRIORITY_MED, messages.METADATA_DEADLINE, 15))
+	queue.Write(messages.NewQueueControlMessage(messages.FLUSH))
+	if equalInts(receivedHeaders(callback.messagesReceived), []int{2, 3, 1}) != true {
+		t.Error("Expecting messages flushed by deadline")
+	}
+
+	queue.Write(newTimedMessage(4, messages.PRIORITY_LOW, messages.METADATA_DEADLINE, 10))
+	queue.Write(newTimedMessage(5, messages.PRIORITY_HIGH, messages.METADATA_DEADLINE, 20))
+	if queue.Write(messages.NewQueueComparatorControlMessage(messages.SET_COMPARATOR, nil)) != true {
+		t.Error("Expecting a comparator control message without a comparator accepted")
+	}
+	if queue.Comparator != nil || equalInts(receivedHeaders(queue.Snapshot()), []int{5, 4}) != true {
+		t.Error("Expecting the stored messages sorted by priority again")
+	}
+	if queue.Write(messages.NewMessage(messages.SET_COMPARATOR, nil, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting a malformed comparator control message to fail")
+	}
+}
@@ -0,0 +1,124 @@
+//
+//  Debounce_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the Debounce class.
+*/
+
+/*
+Test that only the latest message per key is written after the quiet period.
+*/
+func TestDebounceLatest(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	debounce := &plumbing.Debounce{Quiet: time.Second, Clock: clock,
+		Key:  func(message interfaces.IPipeMessage) string { return message.Header().(string) },
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	message1 := messages.NewMessage(messages.NORMAL, "a", 1, messages.PRIORITY_MED)
+	message2 := messages.NewMessage(messages.NORMAL, "a", 2, messages.PRIORITY_MED)
+	message3 := messages.NewMessage(messages.NORMAL, "b", 3, messages.PRIORITY_MED)
+
+	written1 := debounce.Write(message1)
+	clock.Advance(500 * time.Millisecond)
+	written2 := debounce.Write(message2)
+	written3 := debounce.Write(message3)
+
+	if written1 != true || written2 != true || written3 != true {
+		t.Error("Expecting wrote messages to debounce")
+	}
+
+	// the quiet period of key a restarted with message2
+	clock.Advance(500 * time.Millisecond)
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting received no messages before the quiet period")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if len(callback.messagesReceived) != 2 {
+		t.Fatal("Expecting received 2 messages")
+	}
+	if callback.messagesReceived[0] != message2 {
+		t.Error("Expecting received latest message of key a")
+	}
+	if callback.messagesReceived[1] != message3 {
+		t.Error("Expecting received message of key b")
+	}
+
+	clock.Advance(time.Minute)
+	if len(callback.messagesReceived) != 2 {
+		t.Error("Expecting no further messages")
+	}
+}
+
+/*
+Test that pending messages are merged in coalesce mode.
+*/
+func TestDebounceCoalesce(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	debounce := &plumbing.Debounce{Quiet: time.Second, Mode: plumbing.DEBOUNCE_COALESCE, Clock: clock,
+		Merge: func(pending interfaces.IPipeMessage, message interfaces.IPipeMessage) interfaces.IPipeMessage {
+			pending.SetBody(pending.Body().(int) + message.Body().(int))
+			return pending
+		},
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for i := 1; i <= 4; i++ {
+		debounce.Write(messages.NewMessage(messages.NORMAL, nil, i, messages.PRIORITY_MED))
+	}
+	clock.Advance(time.Second)
+
+	if len(callback.messagesReceived) != 1 {
+		t.Fatal("Expecting received 1 message")
+	}
+	if callback.messagesReceived[0].Body().(int) != 10 {
+		t.Error("Expecting merged body == 10")
+	}
+}
+
+/*
+Test that a FLUSH message writes out pending messages and then passes through.
+*/
+func TestDebounceFlush(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	debounce := &plumbing.Debounce{Quiet: time.Second, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	flush := messages.NewQueueControlMessage(messages.FLUSH)
+	debounce.Write(message)
+	flushWritten := debounce.Write(flush)
+
+	if flushWritten != true {
+		t.Error("Expecting wrote flush message to debounce")
+	}
+	if len(callback.messagesReceived) != 2 {
+		t.Fatal("Expecting received 2 messages")
+	}
+	if callback.messagesReceived[0] != message || callback.messagesReceived[1] != flush {
+		t.Error("Expecting pending message followed by flush message")
+	}
+
+	// the flushed message is not written again
+	clock.Advance(time.Minute)
+	if len(callback.messagesReceived) != 2 {
+		t.Error("Expecting no further messages")
+	}
+}
@@ -0,0 +1,111 @@
+//
+//  Decrypt_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"encoding/json"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the Decrypt class.
+*/
+
+/*
+Test that bodies encrypted with the old and the new key are restored, across a key rotation.
+*/
+func TestDecryptRestoresBody(t *testing.T) {
+	output := &FailingFitting{}
+	decrypt := &plumbing.Decrypt{Name: "decrypt", Keys: testCipherKeys, Pipe: plumbing.Pipe{Output: output}}
+	encrypt := &plumbing.Encrypt{Name: "encrypt", Keys: testCipherKeys, KeyID: "aes128", Pipe: plumbing.Pipe{Output: decrypt}}
+
+	encrypt.Write(messages.NewMessage(messages.NORMAL, 1, map[string]interface{}{"password": "hunter2", "pin": 1234}, messages.PRIORITY_MED))
+	encrypt.Rotate("aes256")
+	encrypt.Write(messages.NewMessage(messages.NORMAL, 2, "plain text", messages.PRIORITY_MED))
+	encrypt.Write(messages.NewQueueControlMessage(messages.FLUSH))
+
+	if len(output.messagesReceived) != 3 {
+		t.Fatal("Expecting both messages decrypted and the flush written through")
+	}
+	body, ok := output.messagesReceived[0].Body().(map[string]interface{})
+	if !ok || body["password"] != "hunter2" || body["pin"] != 1234.0 {
+		t.Error("Expecting the first body restored as JSON values")
+	}
+	if output.messagesReceived[1].Body() != "plain text" {
+		t.Error("Expecting the second body restored")
+	}
+	if messages.Metadata(output.messagesReceived[1], messages.METADATA_ENCRYPTION_KEY_ID) != nil {
+		t.Error("Expecting the key ID cleared once decrypted")
+	}
+	if output.messagesReceived[2].Type() != messages.FLUSH {
+		t.Error("Expecting the flush received unchanged")
+	}
+}
+
+/*
+Test restoring bodies with a custom serialization.
+*/
+func TestDecryptCustomSerialization(t *testing.T) {
+	type secret struct {
+		Password string
+	}
+	output := &FailingFitting{}
+	decrypt := &plumbing.Decrypt{Name: "decrypt", Keys: testCipherKeys, Pipe: plumbing.Pipe{Output: output},
+		Unmarshal: func(data []byte) (interface{}, error) {
+			body := secret{}
+			err := json.Unmarshal(data, &body)
+			return body, err
+		}}
+	encrypt := &plumbing.Encrypt{Name: "encrypt", Keys: testCipherKeys, KeyID: "aes128", Pipe: plumbing.Pipe{Output: decrypt}}
+
+	encrypt.Write(messages.NewMessage(messages.NORMAL, nil, secret{Password: "hunter2"}, messages.PRIORITY_MED))
+	if len(output.messagesReceived) != 1 || output.messagesReceived[0].Body() != (secret{Password: "hunter2"}) {
+		t.Error("Expecting the body restored to its type")
+	}
+}
+
+/*
+Test that unencrypted, tampered and unknown-key messages are not written.
+*/
+func TestDecryptFailures(t *testing.T) {
+	output := &FailingFitting{}
+	decrypt := &plumbing.Decrypt{Name: "decrypt", Keys: plumbing.StaticKeys{"aes256": testCipherKeys["aes256"]}, Pipe: plumbing.Pipe{Output: output}}
+	encrypt := &plumbing.Encrypt{Name: "encrypt", Keys: testCipherKeys, KeyID: "aes256", Pipe: plumbing.Pipe{Output: &FailingFitting{}}}
+
+	tampered := messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)
+	encrypt.Seal(tampered)
+	tampered.Body().([]byte)[len(tampered.Body().([]byte))-1] ^= 1
+	relabelled := messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)
+	encrypt.Seal(relabelled)
+	messages.SetMetadata(relabelled, messages.METADATA_ENCRYPTION_KEY_ID, "aes128")
+	truncated := messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)
+	encrypt.Seal(truncated)
+	truncated.SetBody(truncated.Body().([]byte)[:4])
+	oldKey := messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)
+	encrypt.Rotate("aes128")
+	encrypt.Seal(oldKey)
+	unencrypted := messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)
+	notCiphertext := messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)
+	messages.SetMetadata(notCiphertext, messages.METADATA_ENCRYPTION_KEY_ID, "aes256")
+
+	for _, message := range []interfaces.IPipeMessage{tampered, relabelled, truncated, oldKey, unencrypted, notCiphertext} {
+		if decrypt.Write(message) != false {
+			t.Error("Expecting the message not decrypted")
+		}
+	}
+	if len(output.messagesReceived) != 0 {
+		t.Error("Expecting no message written")
+	}
+	if status := decrypt.Status(); status["failed"] != 6 || status["decrypted"] != 0 {
+		t.Error("Expecting the status to count failed messages")
+	}
+}
@@ -0,0 +1,135 @@
+//
+//  Dedup_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the Dedup class.
+*/
+
+type mapDedupStore struct {
+	keys map[string]time.Time
+}
+
+func (s *mapDedupStore) Seen(key string) (time.Time, bool) {
+	seen, ok := s.keys[key]
+	return seen, ok
+}
+
+func (s *mapDedupStore) Remember(key string, seen time.Time) {
+	s.keys[key] = seen
+}
+
+func (s *mapDedupStore) Forget(key string) {
+	delete(s.keys, key)
+}
+
+func newMessageWithID(id string) interfaces.IPipeMessage {
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	messages.SetMetadata(message, messages.METADATA_ID, id)
+	return message
+}
+
+/*
+Test that duplicate message IDs are dropped within the window.
+*/
+func TestDedupByMessageID(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	dedup := &plumbing.Dedup{Window: time.Minute, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	written1 := dedup.Write(newMessageWithID("1"))
+	written2 := dedup.Write(newMessageWithID("1"))
+	written3 := dedup.Write(newMessageWithID("2"))
+	written4 := dedup.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	if written1 != true || written2 != true || written3 != true || written4 != true {
+		t.Error("Expecting all writes succeeded")
+	}
+	if len(callback.messagesReceived) != 3 {
+		t.Error("Expecting received 3 messages")
+	}
+
+	// after the window the key is forgotten
+	clock.Advance(time.Minute)
+	dedup.Write(newMessageWithID("1"))
+	if len(callback.messagesReceived) != 4 {
+		t.Error("Expecting received message 1 again after the window")
+	}
+}
+
+/*
+Test a key function and the capacity bound.
+*/
+func TestDedupKeyAndCapacity(t *testing.T) {
+	callback := Callback{}
+	dedup := &plumbing.Dedup{Capacity: 2,
+		Key:  func(message interfaces.IPipeMessage) string { return message.Body().(string) },
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for _, body := range []string{"a", "b", "a", "c", "b", "a"} {
+		dedup.Write(messages.NewMessage(messages.NORMAL, nil, body, messages.PRIORITY_MED))
+	}
+
+	// a, b, c pass; a is evicted by c, so the last a passes again while b is still remembered
+	if len(callback.messagesReceived) != 4 {
+		t.Fatal("Expecting received 4 messages")
+	}
+	if callback.messagesReceived[3].Body() != "a" {
+		t.Error("Expecting evicted key a written again")
+	}
+}
+
+/*
+Test that a failed write is not remembered.
+*/
+func TestDedupForgetsFailedWrites(t *testing.T) {
+	output := &FailingFitting{Failing: true}
+	dedup := &plumbing.Dedup{Pipe: plumbing.Pipe{Output: output}}
+
+	failed := dedup.Write(newMessageWithID("1"))
+	output.Failing = false
+	retried := dedup.Write(newMessageWithID("1"))
+
+	if failed != false || retried != true {
+		t.Error("Expecting first write failed and retry succeeded")
+	}
+	if len(output.messagesReceived) != 2 {
+		t.Error("Expecting retry was written to the output")
+	}
+}
+
+/*
+Test that the store detects duplicates across restarts.
+*/
+func TestDedupStore(t *testing.T) {
+	store := &mapDedupStore{keys: make(map[string]time.Time)}
+	callback := Callback{}
+	listener := &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}
+
+	dedup := &plumbing.Dedup{Store: store, Pipe: plumbing.Pipe{Output: listener}}
+	dedup.Write(newMessageWithID("1"))
+
+	// a new Dedup with the same store
+	restarted := &plumbing.Dedup{Store: store, Pipe: plumbing.Pipe{Output: listener}}
+	restarted.Write(newMessageWithID("1"))
+	restarted.Write(newMessageWithID("2"))
+
+	if len(callback.messagesReceived) != 2 {
+		t.Error("Expecting duplicate detected after restart")
+	}
+}
@@ -0,0 +1,137 @@
+//
+//  Encrypt_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"bytes"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the Encrypt class.
+*/
+
+var testCipherKeys = plumbing.StaticKeys{
+	"aes128": []byte("0123456789abcdef"),
+	"aes256": []byte("0123456789abcdef0123456789abcdef"),
+	"short":  []byte("too short"),
+}
+
+/*
+Test that the body of a normal message is replaced with ciphertext, leaving the rest in the clear.
+*/
+func TestEncryptSealsBody(t *testing.T) {
+	output := &FailingFitting{}
+	encrypt := &plumbing.Encrypt{Name: "encrypt", Keys: testCipherKeys, KeyID: "aes256", Pipe: plumbing.Pipe{Output: output}}
+
+	message := messages.NewMessage(messages.NORMAL, "header", map[string]string{"password": "hunter2"}, messages.PRIORITY_HIGH)
+	if encrypt.Write(message) != true || len(output.messagesReceived) != 1 {
+		t.Fatal("Expecting the encrypted message written")
+	}
+	sealed := output.messagesReceived[0]
+	ciphertext, ok := sealed.Body().([]byte)
+	if !ok || bytes.Contains(ciphertext, []byte("hunter2")) {
+		t.Error("Expecting the body replaced by ciphertext")
+	}
+	if sealed.Header() != "header" || sealed.Priority() != messages.PRIORITY_HIGH {
+		t.Error("Expecting the header and priority left in the clear")
+	}
+	if messages.Metadata(sealed, messages.METADATA_ENCRYPTION_KEY_ID) != "aes256" {
+		t.Error("Expecting the key ID in the metadata")
+	}
+
+	encrypt.Write(messages.NewMessage(messages.NORMAL, "header", map[string]string{"password": "hunter2"}, messages.PRIORITY_HIGH))
+	if bytes.Equal(output.messagesReceived[1].Body().([]byte), ciphertext) {
+		t.Error("Expecting a fresh nonce for every message")
+	}
+}
+
+/*
+Test that an encrypted branch of a tee leaves the message of the other branches in the clear.
+*/
+func TestEncryptLeavesSharedMessage(t *testing.T) {
+	clear, encrypted := &FailingFitting{}, &FailingFitting{}
+	split := &plumbing.TeeSplit{}
+	split.Connect(&plumbing.Encrypt{Name: "encrypt", Keys: testCipherKeys, KeyID: "aes256", Pipe: plumbing.Pipe{Output: encrypted}})
+	split.Connect(clear)
+
+	message := messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)
+	if split.Write(message) != true || len(clear.messagesReceived) != 1 || len(encrypted.messagesReceived) != 1 {
+		t.Fatal("Expecting the message written to both branches")
+	}
+	if clear.messagesReceived[0].Body() != "secret" || messages.Metadata(clear.messagesReceived[0], messages.METADATA_ENCRYPTION_KEY_ID) != nil {
+		t.Error("Expecting the clear branch to receive the plaintext")
+	}
+	if _, ok := encrypted.messagesReceived[0].Body().([]byte); !ok {
+		t.Error("Expecting the encrypted branch to receive the ciphertext")
+	}
+
+	// a decrypting branch leaves the ciphertext for the others too
+	ciphertext := encrypted.messagesReceived[0]
+	decrypted := &FailingFitting{}
+	(&plumbing.Decrypt{Name: "decrypt", Keys: testCipherKeys, Pipe: plumbing.Pipe{Output: decrypted}}).Write(ciphertext)
+	if len(decrypted.messagesReceived) != 1 || decrypted.messagesReceived[0].Body() != "secret" {
+		t.Error("Expecting the body restored")
+	}
+	if _, ok := ciphertext.Body().([]byte); !ok || messages.Metadata(ciphertext, messages.METADATA_ENCRYPTION_KEY_ID) != "aes256" {
+		t.Error("Expecting the encrypted message unchanged")
+	}
+}
+
+/*
+Test that control messages are written through untouched.
+*/
+func TestEncryptPassesControlMessages(t *testing.T) {
+	output := &FailingFitting{}
+	encrypt := &plumbing.Encrypt{Name: "encrypt", Keys: testCipherKeys, KeyID: "aes128", Pipe: plumbing.Pipe{Output: output}}
+
+	flush := messages.NewQueueControlMessage(messages.FLUSH)
+	filter := messages.NewFilterControlMessage(messages.FILTER, "filter", nil, nil)
+	if encrypt.Write(flush) != true || encrypt.Write(filter) != true {
+		t.Error("Expecting control messages written through")
+	}
+	if len(output.messagesReceived) != 2 || output.messagesReceived[0] != flush || output.messagesReceived[1] != filter {
+		t.Error("Expecting the control messages received unchanged")
+	}
+	if messages.Metadata(flush, messages.METADATA_ENCRYPTION_KEY_ID) != nil {
+		t.Error("Expecting control messages not encrypted")
+	}
+}
+
+/*
+Test that messages that cannot be encrypted are not written.
+*/
+func TestEncryptFailures(t *testing.T) {
+	output := &FailingFitting{}
+	encrypt := &plumbing.Encrypt{Name: "encrypt", Keys: testCipherKeys, KeyID: "unknown", Pipe: plumbing.Pipe{Output: output}}
+
+	if encrypt.Write(messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)) != false {
+		t.Error("Expecting a message not encrypted with an unknown key")
+	}
+	encrypt.Rotate("short")
+	if encrypt.Write(messages.NewMessage(messages.NORMAL, nil, "secret", messages.PRIORITY_MED)) != false {
+		t.Error("Expecting a message not encrypted with a key of invalid length")
+	}
+	encrypt.Rotate("aes128")
+	unencodable := messages.NewMessage(messages.NORMAL, nil, make(chan int), messages.PRIORITY_MED)
+	if encrypt.Write(unencodable) != false || messages.Metadata(unencodable, messages.METADATA_ENCRYPTION_KEY_ID) != nil {
+		t.Error("Expecting a message with a body that cannot be encoded left unchanged")
+	}
+	if encrypt.Write(&bareMessage{}) != false {
+		t.Error("Expecting a message without metadata not encrypted")
+	}
+	if len(output.messagesReceived) != 0 {
+		t.Error("Expecting no unencrypted message written")
+	}
+	if status := encrypt.Status(); status["keyID"] != "aes128" || status["failed"] != 4 || status["encrypted"] != 0 {
+		t.Error("Expecting the status to count failed messages")
+	}
+}
@@ -0,0 +1,176 @@
+//
+//  Expression_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"strings"
+	"testing"
+)
+
+/*
+Test the Expression class.
+*/
+
+type alertBody struct {
+	Level  int
+	Tags   []string
+	secret string
+}
+
+type alertSource struct{ Kind string }
+
+type alertHeader struct {
+	*alertSource
+	Origin alertSource
+}
+
+/*
+Test evaluating expressions over the type, priority, header, body, metadata and params of a message.
+*/
+func TestExpressionMatch(t *testing.T) {
+	message := messages.NewMessage(messages.NORMAL, map[string]interface{}{"kind": "alert", "count": int64(3)},
+		&alertBody{Level: 7, Tags: []string{"disk", "cpu"}, secret: "hidden"}, messages.PRIORITY_HIGH)
+	messages.SetMetadata(message, messages.METADATA_SOURCE, "core")
+
+	cases := map[string]bool{
+		`priority <= 5 && header.kind == "alert"`:         true,
+		`priority > 5 || header.kind == "info"`:           false,
+		`!(header.kind == "info")`:                        true,
+		`header.count == 3 && header.count >= 2.5`:        true,
+		`body.Level > params.min`:                         true,
+		`body.Tags.1 == "cpu"`:                            true,
+		`body.Tags.2 == null && body.Missing == null`:     true,
+		`body.secret == "hidden"`:                         false,
+		`metadata.source == "core"`:                       true,
+		`type == "` + messages.NORMAL + `"`:               true,
+		`header.kind < "b" && header.kind != "alerts"`:    true,
+		`header.kind > 1 || header.kind == 1`:             false,
+		`header.kind`:                                     false,
+		`true && !false`:                                  true,
+		`body.Level == -7 || (priority == 1 && !(false))`: true,
+	}
+	for source, expected := range cases {
+		expression, err := plumbing.CompileExpression(source)
+		if err != nil {
+			t.Errorf("Expecting %s compiles, got %v", source, err)
+			continue
+		}
+		if expression.Match(message, map[string]int{"min": 5}) != expected {
+			t.Errorf("Expecting %s evaluates to %v", source, expected)
+		}
+	}
+}
+
+/*
+Test that paths through nested structs and embedded pointers evaluate without panicking.
+*/
+func TestExpressionEmbeddedFields(t *testing.T) {
+	cases := map[string]bool{
+		`header.Kind == "x"`:            false,
+		`header.Kind == null`:           true,
+		`header.Origin.Kind == "core"`:  true,
+		`header.alertSource == null`:    true,
+		`!(header.Origin.Kind == null)`: true,
+	}
+	for _, header := range []interface{}{alertHeader{Origin: alertSource{Kind: "core"}}, &alertHeader{Origin: alertSource{Kind: "core"}}} {
+		message := messages.NewMessage(messages.NORMAL, header, nil, messages.PRIORITY_MED)
+		for source, expected := range cases {
+			expression, err := plumbing.CompileExpression(source)
+			if err != nil {
+				t.Errorf("Expecting %s compiles, got %v", source, err)
+				continue
+			}
+			if expression.Match(message, nil) != expected {
+				t.Errorf("Expecting %s evaluates to %v", source, expected)
+			}
+		}
+	}
+
+	message := messages.NewMessage(messages.NORMAL, alertHeader{alertSource: &alertSource{Kind: "x"}}, nil, messages.PRIORITY_MED)
+	if expression, _ := plumbing.CompileExpression(`header.Kind == "x"`); expression.Match(message, nil) != true {
+		t.Error("Expecting the promoted field read through a set embedded pointer")
+	}
+}
+
+/*
+Test that invalid expressions return errors with their position instead of panicking.
+*/
+func TestExpressionErrors(t *testing.T) {
+	cases := map[string]int{
+		`priority <=`:            11,
+		`header.kind == "alert`:  15,
+		`(priority == 1`:         14,
+		`priority == 1 extra`:    14,
+		`unknown == 1`:           0,
+		`priority.level == 1`:    0,
+		`header.kind = "alert"`:  12,
+		`priority == 1 &&`:       16,
+		``:                       0,
+		strings.Repeat("(", 100): 64,
+	}
+	for source, position := range cases {
+		expression, err := plumbing.CompileExpression(source)
+		if expression != nil || err == nil {
+			t.Errorf("Expecting %q fails to compile", source)
+			continue
+		}
+		if err.(*plumbing.ExpressionError).Position != position {
+			t.Errorf("Expecting %q fails at position %d, got %v", source, position, err)
+		}
+	}
+}
+
+/*
+Test that a filter compiles an expression sent by control message.
+*/
+func TestFilterExpressionControlMessage(t *testing.T) {
+	callback := Callback{}
+	filter := &plumbing.Filter{Name: "alerts", Mode: messages.FILTER,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	if filter.Write(messages.NewFilterExpressionControlMessage("alerts", "", `header == "alert"`)) != true {
+		t.Error("Expecting expression accepted")
+	}
+	if filter.Write(messages.NewMessage(messages.NORMAL, "alert", nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting matching message written")
+	}
+	if filter.Write(messages.NewMessage(messages.NORMAL, "info", nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting other message filtered")
+	}
+
+	if filter.Write(messages.NewFilterExpressionControlMessage("alerts", "", `header == `)) != false {
+		t.Error("Expecting invalid expression rejected")
+	}
+	if filter.LastError() == nil || filter.Status()["error"] == nil {
+		t.Error("Expecting the parse error of the control message kept")
+	}
+	if filter.SetExpression(`header ==`) == nil {
+		t.Error("Expecting parse error returned")
+	}
+	if filter.Write(messages.NewMessage(messages.NORMAL, "alert", nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting previous expression still applied")
+	}
+
+	// a filter chain compiles expressions for its predicates
+	chain := &plumbing.FilterChain{Name: "chain",
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	chain.Write(messages.NewFilterExpressionControlMessage("chain", "urgent", `priority == 1`))
+	if chain.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_HIGH)) != true ||
+		chain.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_LOW)) != false {
+		t.Error("Expecting chain predicate compiled from the expression")
+	}
+	if chain.SetExpression("", `priority == 1`) == nil || chain.LastError() == nil || chain.Status()["error"] == nil {
+		t.Error("Expecting expression without a predicate name rejected by the chain")
+	}
+	if chain.SetExpression("urgent", `priority == 2`) != nil || chain.LastError() != nil {
+		t.Error("Expecting the error cleared by a valid expression")
+	}
+}
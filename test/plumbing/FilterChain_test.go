@@ -0,0 +1,120 @@
+//
+//  FilterChain_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the FilterChain class.
+*/
+
+/*
+Test that a message is written only if every predicate in the chain passes it.
+*/
+func TestFilterChain(t *testing.T) {
+	callback := Callback{}
+	chain := &plumbing.FilterChain{Name: "chain",
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	chain.Add("above", above, 3)
+	chain.Add("even", even, nil)
+
+	names := chain.Predicates()
+	if len(names) != 2 || names[0] != "above" || names[1] != "even" {
+		t.Error("Expecting predicates in the order added")
+	}
+
+	if chain.Write(messages.NewMessage(messages.NORMAL, 4, nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting message passing every predicate written")
+	}
+	if chain.Write(messages.NewMessage(messages.NORMAL, 5, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting message failing a predicate filtered")
+	}
+	if chain.Write(messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting message failing the first predicate filtered")
+	}
+	if len(callback.messagesReceived) != 1 || callback.messagesReceived[0].Header() != 4 {
+		t.Error("Expecting received only the passing message")
+	}
+
+	if chain.Remove("above") != true || chain.Write(messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting removed predicate no longer applied")
+	}
+	if chain.Remove("above") != false {
+		t.Error("Expecting removing an unknown predicate fails")
+	}
+}
+
+/*
+Test controlling individual predicates and the whole chain by control message.
+*/
+func TestFilterChainControlMessages(t *testing.T) {
+	callback := Callback{}
+	chain := &plumbing.FilterChain{Name: "chain",
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	chain.Add("above", above, 3)
+	chain.Add("even", even, nil)
+
+	message2 := messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED)
+	message5 := messages.NewMessage(messages.NORMAL, 5, nil, messages.PRIORITY_MED)
+
+	// new parameters for one predicate
+	if chain.Write(messages.NewFilterChainControlMessage(messages.SET_PARAMS, "chain", "above", nil, 1)) != true {
+		t.Error("Expecting parameters accepted")
+	}
+	if chain.Write(message2) != true {
+		t.Error("Expecting message passing the new parameters written")
+	}
+
+	// bypass one predicate, then enable it again
+	chain.Write(messages.NewFilterChainControlMessage(messages.BYPASS, "chain", "even", nil, nil))
+	if chain.Write(message5) != true {
+		t.Error("Expecting bypassed predicate skipped")
+	}
+	chain.Write(messages.NewFilterChainControlMessage(messages.FILTER, "chain", "even", nil, nil))
+	if chain.Write(message5) != false {
+		t.Error("Expecting enabled predicate applied again")
+	}
+
+	// replace one predicate
+	chain.Write(messages.NewFilterChainControlMessage(messages.SET_FILTER, "chain", "even", plumbing.Not(even), nil))
+	if chain.Write(message5) != true || chain.Write(message2) != false {
+		t.Error("Expecting replaced predicate applied")
+	}
+
+	// add a predicate
+	chain.Write(messages.NewFilterChainControlMessage(messages.SET_FILTER, "chain", "small", plumbing.Not(above), nil))
+	chain.Write(messages.NewFilterChainControlMessage(messages.SET_PARAMS, "chain", "small", nil, 4))
+	if len(chain.Predicates()) != 3 || chain.Write(message5) != false {
+		t.Error("Expecting new predicate added to the end of the chain")
+	}
+
+	// bypass the whole chain
+	chain.Write(messages.NewFilterControlMessage(messages.BYPASS, "chain", nil, nil))
+	if chain.Write(message2) != true {
+		t.Error("Expecting bypassed chain writes every message")
+	}
+
+	if chain.Write(messages.NewFilterChainControlMessage(messages.SET_PARAMS, "chain", "unknown", nil, 1)) != false {
+		t.Error("Expecting parameters for an unknown predicate rejected")
+	}
+
+	// control messages for other fittings pass through
+	received := len(callback.messagesReceived)
+	other := messages.NewFilterControlMessage(messages.BYPASS, "other", nil, nil)
+	queue := messages.NewQueueControlMessage(messages.FLUSH)
+	chain.Write(other)
+	chain.Write(queue)
+	if len(callback.messagesReceived) != received+2 || callback.messagesReceived[received] != other || callback.messagesReceived[received+1] != queue {
+		t.Error("Expecting control messages for other fittings written through")
+	}
+}
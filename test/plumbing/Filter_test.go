@@ -418,3 +418,35 @@ func TestUseFilterToStopAMessage(t *testing.T) {
 		t.Error("Expecting received == message2")
 	}
 }
+
+/*
+Test that a control message type on a message that is not a filter control message is rejected.
+*/
+func TestMalformedFilterControlMessage(t *testing.T) {
+	callback := Callback{}
+	filter := &plumbing.Filter{Name: "TestFilter", Mode: messages.FILTER, Params: 1,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for _, _type := range []string{messages.SET_PARAMS, messages.SET_FILTER, messages.SET_EXPRESSION, messages.BYPASS, messages.FILTER} {
+		if filter.Write(messages.NewMessage(_type, nil, nil, messages.PRIORITY_MED)) != false {
+			t.Error("Expecting malformed control message rejected")
+		}
+	}
+	if filter.Mode != messages.FILTER || filter.Params != 1 || len(callback.messagesReceived) != 0 {
+		t.Error("Expecting filter unchanged and nothing written")
+	}
+
+	// any IFilterControl is accepted
+	var control interfaces.IFilterControl = messages.NewFilterControlMessage(messages.SET_PARAMS, "TestFilter", nil, 2)
+	if filter.Write(control) != true || filter.Params != 2 {
+		t.Error("Expecting filter control message accepted")
+	}
+
+	// a nil filter function is rejected
+	if filter.Write(messages.NewFilterControlMessage(messages.SET_FILTER, "TestFilter", nil, nil)) != false {
+		t.Error("Expecting nil filter function rejected")
+	}
+	if filter.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting message passed without a filter function")
+	}
+}
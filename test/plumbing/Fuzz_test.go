@@ -0,0 +1,417 @@
+//
+//  Fuzz_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"errors"
+	"fmt"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+Fuzz every fitting with random sequences of messages.
+
+Each input is decoded into a sequence of operations: writing
+a well-formed or malformed message of any known type, advancing
+the clock, or toggling whether the output fails. A fitting
+must never panic or deadlock, whatever it is written.
+
+Run a single fitting with e.g. go test ./test/plumbing -fuzz FuzzFilter
+*/
+
+// fuzzTypes Every message type the fittings act on.
+var fuzzTypes = []string{
+	messages.NORMAL, messages.FLUSH, messages.SORT, messages.FIFO, messages.STATUS, messages.GAP,
+	messages.FLUSH_N, messages.FLUSH_WHERE, messages.PURGE, messages.PAUSE, messages.RESUME,
+	messages.SET_PARAMS, messages.SET_FILTER, messages.SET_EXPRESSION, messages.BYPASS, messages.FILTER,
+	messages.SET_TRANSFORM_PARAMS, messages.SET_TRANSFORM, messages.BYPASS_TRANSFORM, messages.TRANSFORM,
+	messages.BREAKER_OPEN, messages.BREAKER_CLOSE, messages.BREAKER_RESET, messages.SET_RATE, messages.SET_WINDOW,
+	messages.CANCEL, messages.SET_COMPARATOR, "http://example.com/custom",
+}
+
+var fuzzExpressions = []string{`header == 1`, `header.Kind == "x"`, `header.Inner.Kind != null && header.Level >= 0`, `priority <= 5 && body == "text"`, `metadata.id != null`, `header ==`, `((`, ``}
+
+var fuzzFilters = []func(interfaces.IPipeMessage, interface{}) bool{
+	nil,
+	func(message interfaces.IPipeMessage, params interface{}) bool { return true },
+	func(message interfaces.IPipeMessage, params interface{}) bool {
+		header, ok := message.Header().(int)
+		return ok && header%2 == 0
+	},
+}
+
+var fuzzTransforms = []func(interfaces.IPipeMessage, interface{}) (interfaces.IPipeMessage, error){
+	nil,
+	func(message interfaces.IPipeMessage, params interface{}) (interfaces.IPipeMessage, error) {
+		return message, nil
+	},
+	func(message interfaces.IPipeMessage, params interface{}) (interfaces.IPipeMessage, error) {
+		return nil, nil
+	},
+	func(message interfaces.IPipeMessage, params interface{}) (interfaces.IPipeMessage, error) {
+		return nil, errors.New("failed")
+	},
+}
+
+// fuzzInner, fuzzOuter and fuzzNested Struct headers with embedded and nested fields.
+type fuzzInner struct{ Kind string }
+type fuzzOuter struct {
+	*fuzzInner
+	Level int
+}
+type fuzzNested struct{ Inner fuzzInner }
+
+// fuzzMessage A custom message implementation, so fittings can't rely on the concrete message types.
+type fuzzMessage struct {
+	messages.Message
+}
+
+// fuzzSink A terminal output, safe for concurrent writes, that fails on demand.
+type fuzzSink struct {
+	received int
+	failing  bool
+	mutex    sync.Mutex
+}
+
+func (s *fuzzSink) Connect(output interfaces.IPipeFitting) bool {
+	return false
+}
+
+func (s *fuzzSink) Disconnect() interfaces.IPipeFitting {
+	return nil
+}
+
+func (s *fuzzSink) Write(message interfaces.IPipeMessage) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.received++
+	return !s.failing
+}
+
+func (s *fuzzSink) toggle() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failing = !s.failing
+}
+
+// fuzzReader Reads the bytes of a fuzz input, then zeros once it is exhausted.
+type fuzzReader struct {
+	data []byte
+}
+
+func (r *fuzzReader) next() int {
+	if len(r.data) == 0 {
+		return 0
+	}
+	value := r.data[0]
+	r.data = r.data[1:]
+	return int(value)
+}
+
+func (r *fuzzReader) pick(n int) int {
+	return r.next() % n
+}
+
+// message Decode a message of any known type, well-formed or not.
+func (r *fuzzReader) message() interfaces.IPipeMessage {
+	_type := fuzzTypes[r.pick(len(fuzzTypes))]
+	name := []string{"fuzz", "other", ""}[r.pick(3)]
+
+	var header interface{}
+	switch r.pick(5) {
+	case 1:
+		header = r.next()
+	case 2:
+		header = "header"
+	case 3: // a promoted field, possibly behind a nil embedded pointer
+		header = fuzzOuter{Level: r.next()}
+		if r.pick(2) == 1 {
+			header = fuzzOuter{fuzzInner: &fuzzInner{Kind: "x"}}
+		}
+	case 4:
+		header = &fuzzNested{Inner: fuzzInner{Kind: "header"}}
+	}
+	var body interface{}
+	switch r.pick(3) {
+	case 1:
+		body = []interfaces.IPipeMessage{messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)}
+	case 2:
+		body = "text"
+	}
+
+	var message interfaces.IPipeMessage
+	switch r.pick(3) {
+	case 0: // a plain message, malformed for control types
+		message = messages.NewMessage(_type, header, body, r.pick(12))
+	case 1: // a custom implementation, malformed for control types
+		message = &fuzzMessage{}
+		message.SetType(_type)
+		message.SetHeader(header)
+		message.SetBody(body)
+	default: // the control message for the type
+		switch _type {
+		case messages.SET_PARAMS, messages.SET_FILTER, messages.BYPASS, messages.FILTER:
+			message = messages.NewFilterChainControlMessage(_type, name, []string{"", "first", "second"}[r.pick(3)], fuzzFilters[r.pick(len(fuzzFilters))], r.next())
+		case messages.SET_EXPRESSION:
+			message = messages.NewFilterExpressionControlMessage(name, []string{"", "first"}[r.pick(2)], fuzzExpressions[r.pick(len(fuzzExpressions))])
+		case messages.SET_TRANSFORM_PARAMS, messages.SET_TRANSFORM, messages.BYPASS_TRANSFORM, messages.TRANSFORM:
+			message = messages.NewTransformerControlMessage(_type, name, fuzzTransforms[r.pick(len(fuzzTransforms))], r.next())
+		case messages.BREAKER_OPEN, messages.BREAKER_CLOSE, messages.BREAKER_RESET:
+			message = messages.NewCircuitBreakerControlMessage(_type, name)
+		case messages.SET_RATE:
+			message = messages.NewThrottleControlMessage(_type, name, float64(r.next())/10, r.pick(5))
+		case messages.SET_WINDOW:
+			kind := []string{plumbing.WINDOW_TUMBLING, plumbing.WINDOW_SLIDING, plumbing.WINDOW_SESSION, "unknown"}[r.pick(4)]
+			message = messages.NewWindowControlMessage(_type, name, kind, time.Duration(r.pick(20))*time.Second, time.Duration(r.pick(20))*time.Second, time.Duration(r.pick(20))*time.Second)
+		case messages.CANCEL:
+			message = messages.NewSchedulerControlMessage(_type, name, fmt.Sprint(r.pick(8)))
+		case messages.STATUS:
+			message = messages.NewStatusMessage(name)
+		case messages.GAP:
+			message = messages.NewSequenceGapMessage(name, r.next(), r.next())
+		case messages.FLUSH_N:
+			control := messages.NewQueueCountControlMessage(_type, r.pick(4)-1)
+			control.SetName(name)
+			message = control
+		case messages.FLUSH_WHERE, messages.PURGE:
+			control := messages.NewQueueSelectControlMessage(_type, nil, fuzzExpressions[r.pick(len(fuzzExpressions))])
+			control.SetName(name)
+			message = control
+		case messages.SET_COMPARATOR:
+			control := messages.NewQueueComparatorControlMessage(_type, []plumbing.Comparator{nil, plumbing.ByPriority, plumbing.ByAge}[r.pick(3)])
+			control.SetName(name)
+			message = control
+		case messages.FLUSH, messages.SORT, messages.FIFO, messages.PAUSE, messages.RESUME:
+			message = messages.NewNamedQueueControlMessage(_type, name)
+		default:
+			message = messages.NewMessage(_type, header, body, messages.PRIORITY_MED)
+		}
+	}
+
+	if r.pick(2) == 1 {
+		messages.SetMetadata(message, messages.METADATA_ID, r.pick(8))
+		messages.SetMetadata(message, messages.METADATA_TIMESTAMP, epoch.Add(time.Duration(r.next())*time.Second))
+	}
+	if r.pick(2) == 1 {
+		messages.SetMetadata(message, messages.METADATA_DELAY, time.Duration(r.next()-2)*time.Second)
+	}
+	return message
+}
+
+// fuzzFitting Fuzz the fitting made by build, which is written to through its input and writes to the sink.
+func fuzzFitting(f *testing.F, build func(clock *FakeClock, sink *fuzzSink) (input interfaces.IPipeFitting)) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 0, 0, 1, 1, 1, 2, 2, 1, 0, 0, 0, 0, 0})
+	for index := range fuzzTypes {
+		for form := 0; form < 3; form++ {
+			f.Add([]byte{1, byte(index), 0, 1, 1, byte(form), 1, 5, 0, 30, 2, 1, 0, 0, 0, 0, 0, 1, 1, 2, 0, 0, 1, 0, 0, 200})
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		clock := NewFakeClock()
+		sink := &fuzzSink{}
+		input := build(clock, sink)
+		reader := &fuzzReader{data: data}
+
+		for len(reader.data) > 0 {
+			switch reader.pick(8) {
+			case 0:
+				clock.Advance(time.Duration(reader.next()) * 100 * time.Millisecond)
+			case 1:
+				sink.toggle()
+			default:
+				input.Write(reader.message())
+			}
+		}
+		clock.Advance(time.Hour)
+	})
+}
+
+func FuzzPipe(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Pipe{Output: sink}
+	})
+}
+
+func FuzzPipeListener(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.PipeListener{Context: sink, Listener: func(message interfaces.IPipeMessage) { sink.Write(message) }}
+	})
+}
+
+func FuzzFilter(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Filter{Name: "fuzz", Mode: messages.FILTER, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzFilterChain(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		chain := &plumbing.FilterChain{Name: "fuzz", Pipe: plumbing.Pipe{Output: sink}}
+		chain.Add("first", fuzzFilters[2], nil)
+		return chain
+	})
+}
+
+func FuzzQueue(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Queue{Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzNamedQueue(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Queue{Name: "fuzz", Pipe: plumbing.Pipe{Output: &plumbing.Queue{Pipe: plumbing.Pipe{Output: sink}}}}
+	})
+}
+
+func FuzzTeeSplit(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		split := &plumbing.TeeSplit{}
+		split.Connect(sink)
+		split.Connect(&plumbing.Filter{Name: "fuzz", Mode: messages.FILTER, Pipe: plumbing.Pipe{Output: sink}})
+		split.Disconnect()
+		split.Disconnect()
+		split.Disconnect()
+		split.Connect(sink)
+		return split
+	})
+}
+
+func FuzzParallelSplit(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		split := &plumbing.ParallelSplit{Workers: 1, Quorum: 1}
+		split.Connect(sink)
+		split.Connect(&plumbing.Pipe{Output: sink})
+		return split
+	})
+}
+
+func FuzzTeeMerge(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		input := &plumbing.Pipe{}
+		merge := &plumbing.TeeMerge{StampSource: true, Async: true, Pipe: plumbing.Pipe{Output: sink}}
+		merge.ConnectLabeledInput(input, "fuzz")
+		return input
+	})
+}
+
+func FuzzOrderedMerge(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		input := &plumbing.Pipe{}
+		merge := &plumbing.OrderedMerge{AllowedLateness: time.Second, LateOutput: sink, Pipe: plumbing.Pipe{Output: sink}}
+		merge.ConnectInput(input)
+		return input
+	})
+}
+
+func FuzzTransformer(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Transformer{Name: "fuzz", Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzCircuitBreaker(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.CircuitBreaker{Name: "fuzz", FailureThreshold: 2, FailureRatio: 0.5, WindowSize: 4,
+			OpenTimeout: time.Second, Fallback: sink, Clock: clock, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+// Throttle in THROTTLE_DELAY mode is left out, since it blocks until the clock advances.
+func FuzzThrottle(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Throttle{Name: "fuzz", Rate: 1, Burst: 2, Mode: plumbing.THROTTLE_QUEUE,
+			Key: func(message interfaces.IPipeMessage) string { return message.Type() }, Clock: clock, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzDebounce(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Debounce{Quiet: time.Second, Mode: plumbing.DEBOUNCE_COALESCE, Clock: clock, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzAggregator(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Aggregator{MaxCount: 3, MaxBytes: 16, MaxAge: time.Second, Clock: clock,
+			Correlation: func(message interfaces.IPipeMessage) string { return message.Type() }, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzSplitter(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Splitter{Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzDedup(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Dedup{Window: time.Second, Capacity: 4, Clock: clock, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzResequencer(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Resequencer{GapTimeout: time.Second, Mode: plumbing.GAP_NOTIFY, Clock: clock,
+			Stream: func(message interfaces.IPipeMessage) string { return message.Type() }, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzWindow(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Window{Name: "fuzz", Kind: plumbing.WINDOW_SLIDING, Size: 2 * time.Second, Slide: time.Second,
+			Clock: clock, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzWindowEventTime(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Window{Name: "fuzz", Kind: plumbing.WINDOW_SESSION, Gap: time.Second, EventTime: true,
+			Clock: clock, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzScheduler(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Scheduler{Name: "fuzz", Clock: clock, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzSignerVerifier(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		verifier := &plumbing.Verifier{Name: "fuzz", Keys: testKeys, Quarantine: sink, Pipe: plumbing.Pipe{Output: sink}}
+		return &plumbing.Signer{Name: "fuzz", Keys: testKeys, KeyID: "2019-01", Pipe: plumbing.Pipe{Output: verifier}}
+	})
+}
+
+func FuzzVerifier(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Verifier{Name: "fuzz", Keys: testKeys, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
+
+func FuzzEncryptDecrypt(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		decrypt := &plumbing.Decrypt{Name: "fuzz", Keys: testCipherKeys, Pipe: plumbing.Pipe{Output: sink}}
+		return &plumbing.Encrypt{Name: "fuzz", Keys: testCipherKeys, KeyID: "aes128", Pipe: plumbing.Pipe{Output: decrypt}}
+	})
+}
+
+func FuzzDecrypt(f *testing.F) {
+	fuzzFitting(f, func(clock *FakeClock, sink *fuzzSink) interfaces.IPipeFitting {
+		return &plumbing.Decrypt{Name: "fuzz", Keys: testCipherKeys, Pipe: plumbing.Pipe{Output: sink}}
+	})
+}
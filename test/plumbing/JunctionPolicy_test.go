@@ -0,0 +1,291 @@
+//
+//  JunctionPolicy_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"bytes"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+Test the JunctionPolicy, RulePolicy and AuditLog classes.
+*/
+
+const testPolicy = `{
+	"default": "deny",
+	"rules": [
+		{"effect": "deny", "senders": ["untrusted/*"]},
+		{"effect": "allow", "direction": "output", "pipes": ["plugin/*"]},
+		{"effect": "allow", "direction": "input", "types": ["http://example.com/pipes/*"]}
+	]
+}`
+
+// newTypedMessage Create a message of a type.
+func newTypedMessage(_type string) interfaces.IPipeMessage {
+	return messages.NewMessage(_type, nil, nil, messages.PRIORITY_MED)
+}
+
+/*
+Test loading a rule policy from a file, and its decisions.
+*/
+func TestLoadRulePolicy(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(filename, []byte(testPolicy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := plumbing.LoadRulePolicy(filename)
+	if err != nil {
+		t.Fatal("Expecting the policy loaded, got", err)
+	}
+	if len(policy.Rules) != 3 || policy.Default != plumbing.POLICY_DENY {
+		t.Error("Expecting three rules denying by default")
+	}
+
+	allowed := func(pipe string, direction plumbing.PipeDirection, _type string, sender string) bool {
+		result, _ := policy.Allow(plumbing.NewPolicyRequest(pipe, direction, newTypedMessage(_type), sender))
+		return result
+	}
+	if allowed("plugin/a", plumbing.OUTPUT, messages.NORMAL, "core") != true {
+		t.Error("Expecting messages allowed on plugin output pipes")
+	}
+	if allowed("plugin/a", plumbing.OUTPUT, messages.NORMAL, "untrusted/x") != false {
+		t.Error("Expecting messages from untrusted senders denied by the first rule")
+	}
+	if allowed("core", plumbing.OUTPUT, messages.NORMAL, "") != false {
+		t.Error("Expecting messages on other output pipes denied by default")
+	}
+	if allowed("in", plumbing.INPUT, "http://example.com/pipes/hello", "") != true {
+		t.Error("Expecting messages of allowed types delivered")
+	}
+	if allowed("in", plumbing.INPUT, messages.NORMAL, "") != false {
+		t.Error("Expecting messages of other types not delivered")
+	}
+	if _, reason := policy.Allow(plumbing.NewPolicyRequest("in", plumbing.INPUT, newTypedMessage(messages.NORMAL), "")); reason == "" {
+		t.Error("Expecting a reason for a denial")
+	}
+
+	if _, err := plumbing.LoadRulePolicy(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expecting a missing file to fail")
+	}
+}
+
+/*
+Test that * matches / in patterns of types and senders, but not of pipe names.
+*/
+func TestRulePolicyPatterns(t *testing.T) {
+	policy, err := plumbing.ParseRulePolicy([]byte(`{
+		"default": "allow",
+		"rules": [
+			{"effect": "deny", "pipes": ["plugin/*"], "senders": ["team/*"]},
+			{"effect": "allow", "types": ["http://example.com/[a-c]?*/ok"]},
+			{"effect": "deny", "types": ["*"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal("Expecting the policy parsed, got", err)
+	}
+	allowed := func(pipe string, _type string, sender string) bool {
+		result, _ := policy.Allow(plumbing.NewPolicyRequest(pipe, plumbing.OUTPUT, newTypedMessage(_type), sender))
+		return result
+	}
+	if allowed("out", messages.NORMAL, "") != false || allowed("out", "custom", "") != false {
+		t.Error("Expecting * to match every message type")
+	}
+	if allowed("out", "http://example.com/b/c/ok", "") != true || allowed("out", "http://example.com/d/ok", "") != false {
+		t.Error("Expecting ? and classes matched as in path.Match, and * across /")
+	}
+	if allowed("plugin/a", "http://example.com/ab/ok", "team/a/b") != false {
+		t.Error("Expecting * to match / in senders")
+	}
+	if allowed("plugin/a/b", "http://example.com/ab/ok", "team/a/b") != true {
+		t.Error("Expecting * not to match / in pipe names")
+	}
+}
+
+/*
+Test that invalid policies are rejected.
+*/
+func TestInvalidRulePolicy(t *testing.T) {
+	for _, source := range []string{
+		`{"rules": [`,
+		`{"default": "maybe"}`,
+		`{"rules": [{"effect": "permit"}]}`,
+		`{"rules": [{"effect": "allow", "direction": "sideways"}]}`,
+		`{"rules": [{"effect": "allow", "pipes": ["["]}]}`,
+	} {
+		if _, err := plumbing.ParseRulePolicy([]byte(source)); err == nil {
+			t.Error("Expecting an invalid policy rejected: " + source)
+		}
+	}
+}
+
+/*
+Test that a junction consults its policy when sending, and records denials.
+*/
+func TestJunctionPolicySend(t *testing.T) {
+	policy, _ := plumbing.ParseRulePolicy([]byte(testPolicy))
+	var written bytes.Buffer
+	audit := &plumbing.AuditLog{Writer: &written, Clock: NewFakeClock()}
+
+	junction := plumbing.NewJunction()
+	junction.SetPolicy(policy)
+	junction.SetAuditLog(audit)
+	plugin, core := &FailingFitting{}, &FailingFitting{}
+	junction.RegisterPipe("plugin/a", plumbing.OUTPUT, plugin)
+	junction.RegisterPipe("core", plumbing.OUTPUT, core)
+
+	if junction.Sender("core").SendMessage("plugin/a", newTypedMessage(messages.NORMAL)) != true || len(plugin.messagesReceived) != 1 {
+		t.Error("Expecting the allowed message sent")
+	}
+	if junction.Sender("core").SendMessage("core", newTypedMessage(messages.NORMAL)) != false || len(core.messagesReceived) != 0 {
+		t.Error("Expecting the denied message not sent")
+	}
+
+	report := junction.Sender("untrusted/x").SendToAll(newTypedMessage(messages.NORMAL))
+	if len(report) != 2 || report.OK() != false || len(plugin.messagesReceived) != 1 {
+		t.Error("Expecting the broadcast from an untrusted sender denied on every pipe")
+	}
+
+	denials := audit.Denials()
+	if len(denials) != 3 || denials[0].Pipe != "core" || denials[0].Direction != plumbing.OUTPUT {
+		t.Error("Expecting every denial recorded in order")
+	}
+	if denials[1].Sender != "untrusted/x" || denials[1].Reason == "" || denials[1].Time.IsZero() {
+		t.Error("Expecting the sender, reason and time of a denial recorded")
+	}
+	if strings.Count(written.String(), "\n") != 3 || !strings.Contains(written.String(), `"untrusted/x"`) {
+		t.Error("Expecting a line written for each denial")
+	}
+
+	junction.SetPolicy(nil)
+	if junction.SendMessage("core", newTypedMessage(messages.NORMAL)) != true {
+		t.Error("Expecting all messages allowed without a policy")
+	}
+}
+
+/*
+Test that the sender a policy sees is bound to the handle a message is sent through, not claimed by the message.
+*/
+func TestJunctionSenderIdentity(t *testing.T) {
+	policy, _ := plumbing.ParseRulePolicy([]byte(`{"rules": [{"effect": "allow", "senders": ["core"]}]}`))
+	audit := &plumbing.AuditLog{}
+	junction := plumbing.NewJunction()
+	junction.SetPolicy(policy)
+	junction.SetAuditLog(audit)
+	out := &FailingFitting{}
+	junction.RegisterPipe("out", plumbing.OUTPUT, out, "all")
+
+	// a plugin claiming to be the core in the metadata of its message
+	forged := newTypedMessage(messages.NORMAL)
+	messages.SetMetadata(forged, messages.METADATA_SENDER, "core")
+	plugin := junction.Sender("plugin/a")
+	if plugin.Identity() != "plugin/a" || plugin.SendMessage("out", forged) != false || junction.SendMessage("out", forged) != false {
+		t.Error("Expecting a sender claimed in metadata not trusted")
+	}
+	if report, _ := plugin.SendToMatching("*", forged); report.OK() != false || plugin.SendToGroup("all", forged).OK() != false {
+		t.Error("Expecting every send of the plugin denied")
+	}
+	if denials := audit.Denials(); len(denials) != 4 || denials[0].Sender != "plugin/a" || denials[1].Sender != "" {
+		t.Error("Expecting the bound identity recorded with each denial")
+	}
+
+	core := junction.Sender("core")
+	if core.SendMessage("out", newTypedMessage(messages.NORMAL)) != true || core.SendToAll(newTypedMessage(messages.NORMAL)).OK() != true ||
+		core.SendToMatchingFunc(func(name string) bool { return true }, newTypedMessage(messages.NORMAL)).OK() != true {
+		t.Error("Expecting the messages of the core sent")
+	}
+	if len(out.messagesReceived) != 3 {
+		t.Error("Expecting only the messages of the core received")
+	}
+}
+
+/*
+Test that output pipes handed out by a junction with a policy are subject to it.
+*/
+func TestJunctionPolicyRetrievedPipes(t *testing.T) {
+	policy, _ := plumbing.ParseRulePolicy([]byte(testPolicy))
+	junction := plumbing.NewJunction()
+	plugin, core, in := &FailingFitting{}, &FailingFitting{}, &plumbing.Pipe{}
+	junction.RegisterPipe("plugin/a", plumbing.OUTPUT, plugin)
+	junction.RegisterPipe("core", plumbing.OUTPUT, core)
+	junction.RegisterPipe("in", plumbing.INPUT, in)
+
+	if junction.RetrievePipe("core") != core || junction.Pipes()["core"] != core {
+		t.Error("Expecting the registered pipes without a policy")
+	}
+
+	junction.SetPolicy(policy)
+	if junction.RetrievePipe("core").Write(newTypedMessage(messages.NORMAL)) != false ||
+		junction.Pipes()["core"].Write(newTypedMessage(messages.NORMAL)) != false || len(core.messagesReceived) != 0 {
+		t.Error("Expecting writes to a retrieved output pipe denied by the policy")
+	}
+	if junction.RetrievePipe("plugin/a").Write(newTypedMessage(messages.NORMAL)) != true || len(plugin.messagesReceived) != 1 {
+		t.Error("Expecting writes to a retrieved output pipe allowed by the policy")
+	}
+	if junction.RetrievePipe("in") != in || junction.RetrievePipe("missing") != nil {
+		t.Error("Expecting input pipes returned as they are")
+	}
+}
+
+/*
+Test that a junction consults its policy when delivering to input listeners.
+*/
+func TestJunctionPolicyDeliver(t *testing.T) {
+	policy, _ := plumbing.ParseRulePolicy([]byte(testPolicy))
+	audit := &plumbing.AuditLog{}
+
+	junction := plumbing.NewJunction()
+	junction.SetAuditLog(audit)
+	pipe := &plumbing.Pipe{}
+	junction.RegisterPipe("in", plumbing.INPUT, pipe)
+	callback := &Callback{}
+	junction.AddPipeListener("in", callback, callback.CallbackMethod)
+	junction.SetPolicy(policy)
+
+	if pipe.Write(newTypedMessage("http://example.com/pipes/hello")) != true {
+		t.Error("Expecting the allowed message delivered")
+	}
+	if pipe.Write(newTypedMessage(messages.NORMAL)) != false {
+		t.Error("Expecting the denied message not delivered")
+	}
+	if len(callback.messagesReceived) != 1 || callback.messagesReceived[0].Type() != "http://example.com/pipes/hello" {
+		t.Error("Expecting only the allowed message received")
+	}
+	if denials := audit.Denials(); len(denials) != 1 || denials[0].Direction != plumbing.INPUT {
+		t.Error("Expecting the denied delivery recorded")
+	}
+}
+
+/*
+Test that an audit log keeps only the most recent denials within its limit.
+*/
+func TestAuditLogLimit(t *testing.T) {
+	audit := &plumbing.AuditLog{Limit: 2}
+	for _, pipe := range []string{"a", "b", "c"} {
+		audit.Record(plumbing.NewPolicyRequest(pipe, plumbing.OUTPUT, newTypedMessage(messages.NORMAL), ""), "denied")
+	}
+	denials := audit.Denials()
+	if len(denials) != 2 || denials[0].Pipe != "b" || denials[1].Pipe != "c" || denials[1].Type != messages.NORMAL {
+		t.Error("Expecting the two most recent denials kept")
+	}
+
+	unlimited := &plumbing.AuditLog{}
+	for i := 0; i < plumbing.AUDIT_LOG_LIMIT+10; i++ {
+		unlimited.Record(plumbing.NewPolicyRequest("a", plumbing.OUTPUT, newTypedMessage(messages.NORMAL), ""), "denied")
+	}
+	if len(unlimited.Denials()) != plumbing.AUDIT_LOG_LIMIT {
+		t.Error("Expecting AUDIT_LOG_LIMIT denials kept by default")
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
 	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
 	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -240,3 +242,278 @@ func TestSendMessageOnAnOutputPipe(t *testing.T) {
 	}
 
 }
+
+/*
+Test enumerating the pipes of a junction, before and after removing some.
+*/
+func TestJunctionPipeNames(t *testing.T) {
+	junction := plumbing.NewJunction()
+	junction.RegisterPipe("in1", plumbing.INPUT, &plumbing.Pipe{})
+	junction.RegisterPipe("out1", plumbing.OUTPUT, &plumbing.Pipe{})
+	junction.RegisterPipe("in2", plumbing.INPUT, &plumbing.Pipe{})
+	junction.RegisterPipe("out2", plumbing.OUTPUT, &plumbing.Pipe{})
+
+	if equalStrings(junction.InputPipeNames(), []string{"in1", "in2"}) != true {
+		t.Error("Expecting input pipe names in order registered")
+	}
+	if equalStrings(junction.OutputPipeNames(), []string{"out1", "out2"}) != true {
+		t.Error("Expecting output pipe names in order registered")
+	}
+	if len(junction.Pipes()) != 4 || junction.Pipes()["out2"] != junction.RetrievePipe("out2") {
+		t.Error("Expecting all four pipes by name")
+	}
+
+	junction.RemovePipe("in1")
+	junction.RemovePipe("out2")
+	if equalStrings(junction.InputPipeNames(), []string{"in2"}) != true {
+		t.Error("Expecting removed input pipe no longer listed")
+	}
+	if equalStrings(junction.OutputPipeNames(), []string{"out1"}) != true {
+		t.Error("Expecting removed output pipe no longer listed")
+	}
+	if len(junction.Pipes()) != 2 {
+		t.Error("Expecting two pipes left")
+	}
+	if junction.Direction("in2") != plumbing.INPUT || junction.Direction("in1") != "" {
+		t.Error("Expecting the direction of registered pipes only")
+	}
+}
+
+/*
+Test that pipes with an invalid direction, or nil pipes, are not registered.
+*/
+func TestJunctionRejectsInvalidPipes(t *testing.T) {
+	junction := &plumbing.Junction{}
+
+	if junction.RegisterPipe("sideways", plumbing.PipeDirection("sideways"), &plumbing.Pipe{}) != false {
+		t.Error("Expecting a pipe with an invalid direction rejected")
+	}
+	if junction.HasPipe("sideways") != false || len(junction.Pipes()) != 0 {
+		t.Error("Expecting a rejected pipe not registered")
+	}
+	if junction.RegisterPipe("nil", plumbing.INPUT, nil) != false {
+		t.Error("Expecting a nil pipe rejected")
+	}
+	if junction.RegisterPipe("in", plumbing.INPUT, &plumbing.Pipe{}) != true {
+		t.Error("Expecting the zero value junction to register a pipe")
+	}
+	if junction.RegisterPipe("in", plumbing.OUTPUT, &plumbing.Pipe{}) != false {
+		t.Error("Expecting a second pipe with the same name rejected")
+	}
+	if equalStrings(junction.OutputPipeNames(), []string{}) != true {
+		t.Error("Expecting no output pipes")
+	}
+}
+
+/*
+Test registering, removing, enumerating and sending on pipes from many goroutines.
+*/
+func TestJunctionConcurrentRegistry(t *testing.T) {
+	junction := plumbing.NewJunction()
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	var group sync.WaitGroup
+	for writer := 0; writer < 8; writer++ {
+		group.Add(1)
+		go func(writer int) {
+			defer group.Done()
+			for i := 0; i < 100; i++ {
+				name := strconv.Itoa(writer) + "/" + strconv.Itoa(i)
+				direction := plumbing.INPUT
+				if i%2 == 0 {
+					direction = plumbing.OUTPUT
+				}
+				junction.RegisterPipe(name, direction, &plumbing.Pipe{Output: &plumbing.PipeListener{Listener: func(interfaces.IPipeMessage) {}}})
+				junction.SendMessage(name, message)
+				junction.InputPipeNames()
+				junction.Pipes()
+				if i%4 < 2 {
+					junction.RemovePipe(name)
+				}
+			}
+		}(writer)
+	}
+	group.Wait()
+
+	if len(junction.InputPipeNames()) != 200 || len(junction.OutputPipeNames()) != 200 || len(junction.Pipes()) != 400 {
+		t.Error("Expecting the names listed to match the pipes registered")
+	}
+	for _, name := range append(junction.InputPipeNames(), junction.OutputPipeNames()...) {
+		if junction.HasPipe(name) != true {
+			t.Error("Expecting every listed pipe registered")
+		}
+	}
+}
+
+/*
+Test that a listener can change the junction while a message is sent through it.
+*/
+func TestJunctionSendReentrant(t *testing.T) {
+	junction := plumbing.NewJunction()
+	pipe := &plumbing.Pipe{}
+	pipe.Connect(&plumbing.PipeListener{Context: junction, Listener: func(message interfaces.IPipeMessage) {
+		junction.RemovePipe("out")
+	}})
+	junction.RegisterPipe("out", plumbing.OUTPUT, pipe)
+
+	if junction.SendMessage("out", messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting message sent")
+	}
+	if junction.HasPipe("out") != false {
+		t.Error("Expecting the listener removed the pipe")
+	}
+}
+
+// equalStrings Are two lists of strings equal?
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// newJunctionOutputs Create a junction with a recording OUTPUT pipe of each name, in groups "all" and "even" or "odd" by position, the last one failing.
+func newJunctionOutputs(names ...string) (*plumbing.Junction, map[string]*FailingFitting) {
+	junction := plumbing.NewJunction()
+	outputs := map[string]*FailingFitting{}
+	for index, name := range names {
+		outputs[name] = &FailingFitting{Failing: index == len(names)-1}
+		var groups []string
+		if index%2 == 1 {
+			groups = append(groups, "odd")
+		}
+		if index%2 == 0 {
+			groups = append(groups, "even")
+		}
+		junction.RegisterPipe(name, plumbing.OUTPUT, outputs[name], append(groups, "all")...)
+	}
+	junction.RegisterPipe("input", plumbing.INPUT, &plumbing.Pipe{}, "all")
+	return junction, outputs
+}
+
+/*
+Test sending a message on every output pipe.
+*/
+func TestJunctionSendToAll(t *testing.T) {
+	junction, outputs := newJunctionOutputs("worker/1", "worker/2", "logger")
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	report := junction.SendToAll(message)
+	if len(report) != 3 || report["worker/1"] != true || report["worker/2"] != true || report["logger"] != false {
+		t.Error("Expecting a result for each output pipe")
+	}
+	if report.OK() != false || equalStrings(report.Failed(), []string{"logger"}) != true {
+		t.Error("Expecting the failing pipe reported")
+	}
+	if equalStrings(report.Sent(), []string{"worker/1", "worker/2"}) != true {
+		t.Error("Expecting the accepting pipes reported")
+	}
+	for name, output := range outputs {
+		if len(output.messagesReceived) != 1 || output.messagesReceived[0] != message {
+			t.Error("Expecting the message written to " + name)
+		}
+	}
+}
+
+/*
+Test sending a message on the output pipes whose names match.
+*/
+func TestJunctionSendToMatching(t *testing.T) {
+	junction, outputs := newJunctionOutputs("worker/1", "worker/2", "logger")
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	report, err := junction.SendToMatching("worker/*", message)
+	if err != nil || len(report) != 2 || report.OK() != true {
+		t.Error("Expecting the message sent on both workers")
+	}
+	if len(outputs["logger"].messagesReceived) != 0 {
+		t.Error("Expecting the message not sent on the logger")
+	}
+
+	if _, err := junction.SendToMatching("[", message); err == nil {
+		t.Error("Expecting a malformed pattern rejected")
+	}
+	if len(outputs["worker/1"].messagesReceived) != 1 {
+		t.Error("Expecting nothing sent for a malformed pattern")
+	}
+
+	report = junction.SendToMatchingFunc(func(name string) bool { return name == "worker/2" }, message)
+	if equalStrings(report.Sent(), []string{"worker/2"}) != true || len(outputs["worker/2"].messagesReceived) != 2 {
+		t.Error("Expecting the message sent on the pipe the predicate selects")
+	}
+
+	report, _ = junction.SendToMatching("input", message)
+	if len(report) != 0 {
+		t.Error("Expecting no message sent on input pipes")
+	}
+}
+
+/*
+Test sending a message on the output pipes in a group.
+*/
+func TestJunctionSendToGroup(t *testing.T) {
+	junction, outputs := newJunctionOutputs("a", "b", "c", "d")
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	if equalStrings(junction.Groups("b"), []string{"odd", "all"}) != true {
+		t.Error("Expecting the groups pipe b was registered with")
+	}
+
+	report := junction.SendToGroup("even", message)
+	if equalStrings(report.Sent(), []string{"a", "c"}) != true || len(report) != 2 {
+		t.Error("Expecting the message sent on the even pipes")
+	}
+	if len(outputs["b"].messagesReceived) != 0 || len(outputs["d"].messagesReceived) != 0 {
+		t.Error("Expecting the message not sent on the odd pipes")
+	}
+
+	report = junction.SendToGroup("all", message)
+	if len(report) != 4 || equalStrings(report.Failed(), []string{"d"}) != true {
+		t.Error("Expecting the message sent on every output pipe in the group")
+	}
+
+	junction.RemovePipe("a")
+	junction.RegisterPipe("a", plumbing.OUTPUT, &plumbing.Pipe{})
+	if len(junction.SendToGroup("even", message)) != 1 {
+		t.Error("Expecting the groups of a removed pipe forgotten")
+	}
+	if len(junction.SendToGroup("none", message)) != 0 {
+		t.Error("Expecting an empty report for an unknown group")
+	}
+}
+
+/*
+Test that a junction literal given pipes in the deprecated maps registers them, and keeps the maps up to date.
+*/
+func TestJunctionDeprecatedPipesMap(t *testing.T) {
+	callback := &Callback{}
+	out := &plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}
+	in := &plumbing.Pipe{}
+	junction := &plumbing.Junction{
+		PipesMap:     map[string]interfaces.IPipeFitting{"out": out, "in": in},
+		PipeTypesMap: map[string]string{"out": string(plumbing.OUTPUT), "in": string(plumbing.INPUT)},
+	}
+
+	if junction.HasOutputPipe("out") != true || junction.HasInputPipe("in") != true {
+		t.Error("Expecting the pipes of the literal registered")
+	}
+	if equalStrings(junction.OutputPipeNames(), []string{"out"}) != true || equalStrings(junction.InputPipeNames(), []string{"in"}) != true {
+		t.Error("Expecting the pipes of the literal listed")
+	}
+	if junction.SendMessage("out", messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != true || len(callback.messagesReceived) != 1 {
+		t.Error("Expecting a message sent on the pipe of the literal")
+	}
+
+	junction.RegisterPipe("more", plumbing.OUTPUT, &plumbing.Pipe{})
+	junction.RemovePipe("in")
+	junction.PipesMapMutex.RLock()
+	if len(junction.PipesMap) != 2 || junction.PipesMap["more"] == nil || junction.PipeTypesMap["more"] != string(plumbing.OUTPUT) || junction.PipesMap["in"] != nil {
+		t.Error("Expecting the deprecated maps kept up to date")
+	}
+	junction.PipesMapMutex.RUnlock()
+}
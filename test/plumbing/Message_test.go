@@ -10,6 +10,7 @@ package plumbing
 
 import (
 	"encoding/xml"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
 	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
 	"testing"
 )
@@ -68,3 +69,46 @@ func TestSettersAndGetters(t *testing.T) {
 		t.Error("Expecting message.Priority() == messages.PRIORITY_LOW")
 	}
 }
+
+/*
+  Tests the metadata setters and getters.
+*/
+func TestMetadata(t *testing.T) {
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	if messages.Metadata(message, messages.METADATA_ID) != nil {
+		t.Error("Expecting no message ID")
+	}
+	if messages.SetMetadata(message, messages.METADATA_ID, "message-1") != true {
+		t.Error("Expecting set message ID")
+	}
+	if messages.Metadata(message, messages.METADATA_ID) != "message-1" {
+		t.Error("Expecting message ID == message-1")
+	}
+	if message.(interfaces.IPipeMetadata).Metadata(messages.METADATA_ID) != "message-1" {
+		t.Error("Expecting message implements IPipeMetadata")
+	}
+}
+
+/*
+  Tests copying a message.
+*/
+func TestCopy(t *testing.T) {
+	message := messages.NewMessage(messages.NORMAL, "header", "body", messages.PRIORITY_HIGH)
+	messages.SetMetadata(message, messages.METADATA_ID, "message-1")
+
+	copied := messages.Copy(message)
+	if copied == message || copied.Type() != messages.NORMAL || copied.Header() != "header" ||
+		copied.Body() != "body" || copied.Priority() != messages.PRIORITY_HIGH {
+		t.Error("Expecting a new message with the same type, header, body and priority")
+	}
+	messages.SetMetadata(copied, messages.METADATA_SOURCE, "shell")
+	if messages.Metadata(copied, messages.METADATA_ID) != "message-1" || messages.Metadata(message, messages.METADATA_SOURCE) != nil {
+		t.Error("Expecting the copy to have its own metadata")
+	}
+
+	control := messages.NewQueueControlMessage(messages.FLUSH)
+	if messages.Copy(control) != control {
+		t.Error("Expecting control messages returned as they are")
+	}
+}
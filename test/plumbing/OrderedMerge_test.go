@@ -0,0 +1,212 @@
+//
+//  OrderedMerge_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the OrderedMerge class.
+*/
+
+var epoch = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newEventMessage(second int) interfaces.IPipeMessage {
+	message := messages.NewMessage(messages.NORMAL, second, nil, messages.PRIORITY_MED)
+	messages.SetMetadata(message, messages.METADATA_TIMESTAMP, epoch.Add(time.Duration(second)*time.Second))
+	return message
+}
+
+func receivedSeconds(received []interfaces.IPipeMessage) []int {
+	var seconds []int
+	for _, message := range received {
+		seconds = append(seconds, message.Header().(int))
+	}
+	return seconds
+}
+
+func equalInts(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Test that messages from two inputs are written in timestamp order as the watermark advances.
+*/
+func TestOrderedMergeByTimestamp(t *testing.T) {
+	pipe1 := &plumbing.Pipe{}
+	pipe2 := &plumbing.Pipe{}
+	callback := Callback{}
+	merge := &plumbing.OrderedMerge{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	merge.ConnectInput(pipe1)
+	merge.ConnectInput(pipe2)
+
+	pipe1.Write(newEventMessage(1))
+	pipe1.Write(newEventMessage(4))
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting nothing written until every input has written")
+	}
+
+	pipe2.Write(newEventMessage(2))
+	if !equalInts(receivedSeconds(callback.messagesReceived), []int{1, 2}) {
+		t.Error("Expecting 1 and 2 written")
+	}
+
+	pipe2.Write(newEventMessage(3))
+	pipe2.Write(newEventMessage(5))
+	if !equalInts(receivedSeconds(callback.messagesReceived), []int{1, 2, 3, 4}) {
+		t.Error("Expecting 1 to 4 written in order")
+	}
+
+	// flush from any input writes out the rest, then the flush message
+	pipe1.Write(messages.NewQueueControlMessage(messages.FLUSH))
+	if len(callback.messagesReceived) != 6 {
+		t.Fatal("Expecting received 6 messages")
+	}
+	if !equalInts(receivedSeconds(callback.messagesReceived[:5]), []int{1, 2, 3, 4, 5}) {
+		t.Error("Expecting 5 flushed")
+	}
+	if callback.messagesReceived[5].Type() != messages.FLUSH {
+		t.Error("Expecting flush message written through")
+	}
+}
+
+/*
+Test that late messages go to the late output, within the allowed lateness they do not.
+*/
+func TestOrderedMergeLateMessages(t *testing.T) {
+	pipe1 := &plumbing.Pipe{}
+	pipe2 := &plumbing.Pipe{}
+	callback := Callback{}
+	late := Callback{}
+	merge := &plumbing.OrderedMerge{AllowedLateness: 2 * time.Second,
+		LateOutput: &plumbing.PipeListener{Context: late, Listener: late.CallbackMethod},
+		Pipe:       plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	merge.ConnectInput(pipe1)
+	merge.ConnectInput(pipe2)
+
+	pipe1.Write(newEventMessage(10))
+	pipe2.Write(newEventMessage(10))
+	if merge.Watermark() != epoch.Add(8*time.Second) {
+		t.Error("Expecting watermark at 8s")
+	}
+
+	// within the allowed lateness
+	pipe1.Write(newEventMessage(9))
+	// before the watermark
+	pipe2.Write(newEventMessage(7))
+
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting nothing written yet")
+	}
+	if !equalInts(receivedSeconds(late.messagesReceived), []int{7}) {
+		t.Error("Expecting 7 written to late output")
+	}
+
+	pipe1.Write(newEventMessage(13))
+	pipe2.Write(newEventMessage(12))
+	if !equalInts(receivedSeconds(callback.messagesReceived), []int{9, 10, 10}) {
+		t.Error("Expecting 9, 10, 10 written")
+	}
+}
+
+/*
+Test that disconnecting an idle input releases buffered messages.
+*/
+func TestOrderedMergeDisconnectInput(t *testing.T) {
+	pipe1 := &plumbing.Pipe{}
+	idle := &plumbing.Pipe{}
+	callback := Callback{}
+	merge := &plumbing.OrderedMerge{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	merge.ConnectInput(pipe1)
+	merge.ConnectInput(idle)
+
+	pipe1.Write(newEventMessage(1))
+	if merge.DisconnectInput(idle) != idle {
+		t.Error("Expecting disconnected idle input")
+	}
+	if len(merge.Inputs()) != 1 {
+		t.Error("Expecting 1 input")
+	}
+	if !equalInts(receivedSeconds(callback.messagesReceived), []int{1}) {
+		t.Error("Expecting 1 released")
+	}
+
+	// a tee input keeps its other outputs
+	split := &plumbing.TeeSplit{}
+	other := &Callback{}
+	merge.ConnectInput(split)
+	split.Connect(&plumbing.PipeListener{Context: other, Listener: other.CallbackMethod})
+	if merge.DisconnectInput(split) != split {
+		t.Error("Expecting disconnected split")
+	}
+	split.Write(newEventMessage(2))
+	if len(other.messagesReceived) != 1 || len(callback.messagesReceived) != 1 {
+		t.Error("Expecting split still writes to its other output only")
+	}
+}
+
+/*
+Test that disconnecting a tee input while it writes into the merge does not deadlock.
+*/
+func TestOrderedMergeDisconnectDuringWrite(t *testing.T) {
+	entered := make(chan struct{})
+	gate := make(chan struct{})
+	callback := &Callback{}
+	merge := &plumbing.OrderedMerge{
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}},
+		Timestamp: func(message interfaces.IPipeMessage) (time.Time, bool) {
+			close(entered)
+			<-gate // a slow timestamp, with the tee write in flight
+			return epoch, true
+		},
+	}
+	split := &plumbing.TeeSplit{}
+	merge.ConnectInput(split)
+
+	written := make(chan bool)
+	go func() {
+		written <- split.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_MED))
+	}()
+	<-entered
+
+	disconnected := make(chan interfaces.IPipeFitting)
+	go func() {
+		disconnected <- merge.DisconnectInput(split)
+	}()
+	time.Sleep(20 * time.Millisecond) // let the disconnect wait for the tee
+	close(gate)
+
+	select {
+	case input := <-disconnected:
+		if input != split {
+			t.Error("Expecting disconnected split")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expecting DisconnectInput to return while the tee writes")
+	}
+	if <-written != true || merge.Flush() != true || len(callback.messagesReceived) != 1 {
+		t.Error("Expecting the message in flight buffered, then flushed")
+	}
+	if len(merge.Inputs()) != 0 {
+		t.Error("Expecting no inputs")
+	}
+}
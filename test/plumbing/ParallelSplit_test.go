@@ -0,0 +1,221 @@
+//
+//  ParallelSplit_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+Test the ParallelSplit class.
+*/
+
+// gatedFitting blocks each write until the gate is opened, recording what it was written.
+type gatedFitting struct {
+	plumbing.Pipe
+	gate             chan struct{}
+	messagesReceived []interfaces.IPipeMessage
+	mutex            sync.Mutex
+}
+
+func (f *gatedFitting) Write(message interfaces.IPipeMessage) bool {
+	<-f.gate
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.messagesReceived = append(f.messagesReceived, message)
+	return true
+}
+
+func (f *gatedFitting) received() []interfaces.IPipeMessage {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]interfaces.IPipeMessage(nil), f.messagesReceived...)
+}
+
+/*
+Test that outputs are written concurrently.
+*/
+func TestParallelSplitWritesConcurrently(t *testing.T) {
+	slow1 := &gatedFitting{gate: make(chan struct{})}
+	slow2 := &gatedFitting{gate: make(chan struct{})}
+	split := &plumbing.ParallelSplit{}
+	split.Connect(slow1)
+	split.Connect(slow2)
+
+	// the output connected last completes first, which a sequential write would never reach
+	done := make(chan bool)
+	go func() { done <- split.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) }()
+	slow2.gate <- struct{}{}
+	slow1.gate <- struct{}{}
+
+	select {
+	case written := <-done:
+		if written != true {
+			t.Error("Expecting wrote message to both outputs")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expecting write completed")
+	}
+	if len(slow1.received()) != 1 || len(slow2.received()) != 1 {
+		t.Error("Expecting each output received 1 message")
+	}
+}
+
+/*
+Test that a quorum write returns without waiting for a slow output, which still receives messages in order.
+*/
+func TestParallelSplitQuorumAndOrdering(t *testing.T) {
+	callback := Callback{}
+	fast := &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}
+	slow := &gatedFitting{gate: make(chan struct{})}
+	split := &plumbing.ParallelSplit{Quorum: 1}
+	split.Connect(fast)
+	split.Connect(slow)
+
+	var sent []interfaces.IPipeMessage
+	for i := 0; i < 5; i++ {
+		message := messages.NewMessage(messages.NORMAL, i, nil, messages.PRIORITY_MED)
+		sent = append(sent, message)
+		report := split.WriteReport(message)
+		if report.Success != true {
+			t.Error("Expecting quorum reached")
+		}
+		if len(report.Succeeded) != 1 || report.Succeeded[0] != fast {
+			t.Error("Expecting fast output succeeded")
+		}
+		if len(report.Pending) != 1 || report.Pending[0] != slow {
+			t.Error("Expecting slow output pending")
+		}
+	}
+
+	close(slow.gate)
+	deadline := time.Now().Add(time.Second)
+	for len(slow.received()) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	received := slow.received()
+	if len(received) != 5 {
+		t.Fatal("Expecting slow output received 5 messages")
+	}
+	for index, message := range received {
+		if message != sent[index] {
+			t.Error("Expecting slow output received messages in order")
+		}
+	}
+}
+
+/*
+Test that a full backlog on a slow output neither blocks writes to the others nor its disconnection.
+*/
+func TestParallelSplitFullBacklog(t *testing.T) {
+	fast := &gatedFitting{gate: make(chan struct{})}
+	close(fast.gate)
+	slow := &gatedFitting{gate: make(chan struct{})}
+	split := &plumbing.ParallelSplit{Quorum: 1}
+	split.Connect(slow)
+	split.Connect(fast)
+
+	// the writer blocks once the backlog of the slow output is full
+	written := make(chan *plumbing.SplitReport, 1000)
+	go func() {
+		for i := 0; i < 200; i++ {
+			written <- split.WriteReport(messages.NewMessage(messages.NORMAL, i, nil, messages.PRIORITY_MED))
+		}
+		close(written)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for len(written) < 129 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// another writer still gets through to the fast output, then waits for room in the backlog
+	other := make(chan bool)
+	go func() {
+		other <- split.WriteReport(messages.NewMessage(messages.NORMAL, -1, nil, messages.PRIORITY_MED)).Success
+	}()
+	for len(fast.received()) < 131 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if received := fast.received(); len(received) != 131 || equalInts(receivedHeaders(received[129:]), []int{129, -1}) != true &&
+		equalInts(receivedHeaders(received[129:]), []int{-1, 129}) != true {
+		t.Fatal("Expecting the other writer's message written to the fast output")
+	}
+
+	disconnected := make(chan interfaces.IPipeFitting)
+	go func() { disconnected <- split.DisconnectFitting(slow) }()
+	select {
+	case output := <-disconnected:
+		if output != slow {
+			t.Error("Expecting the slow output disconnected")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expecting the slow output disconnected while writers wait for it")
+	}
+
+	var reports []*plumbing.SplitReport
+	for report := range written {
+		reports = append(reports, report)
+	}
+	if len(reports) != 200 || reports[len(reports)-1].Success != true || len(reports[len(reports)-1].Succeeded) != 1 {
+		t.Error("Expecting the writes to complete on the fast output")
+	}
+	if <-other != true {
+		t.Error("Expecting the other write reached its quorum")
+	}
+	close(slow.gate)
+}
+
+/*
+Test that failed outputs are reported.
+*/
+func TestParallelSplitReportsFailures(t *testing.T) {
+	good := &FailingFitting{}
+	bad := &FailingFitting{Failing: true}
+
+	var failedMutex sync.Mutex
+	var failed []interfaces.IPipeFitting
+	split := &plumbing.ParallelSplit{Workers: 1,
+		Failure: func(output interfaces.IPipeFitting, message interfaces.IPipeMessage) {
+			failedMutex.Lock()
+			defer failedMutex.Unlock()
+			failed = append(failed, output)
+		}}
+	split.Connect(good)
+	split.Connect(bad)
+
+	report := split.WriteReport(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	if report.Success != false {
+		t.Error("Expecting write failed")
+	}
+	if len(report.Failed) != 1 || report.Failed[0] != bad {
+		t.Error("Expecting bad output reported failed")
+	}
+	if len(report.Succeeded) != 1 || report.Succeeded[0] != good {
+		t.Error("Expecting good output reported succeeded")
+	}
+	failedMutex.Lock()
+	if len(failed) != 1 || failed[0] != bad {
+		t.Error("Expecting failure callback for bad output")
+	}
+	failedMutex.Unlock()
+
+	// disconnected outputs are no longer written
+	if split.DisconnectFitting(bad) != bad {
+		t.Error("Expecting disconnected bad output")
+	}
+	if split.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting write succeeded without bad output")
+	}
+}
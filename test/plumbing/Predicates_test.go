@@ -0,0 +1,92 @@
+//
+//  Predicates_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the predicate combinators.
+*/
+
+// above Pass messages whose int header is above the int params.
+func above(message interfaces.IPipeMessage, params interface{}) bool {
+	return message.Header().(int) > params.(int)
+}
+
+// even Pass messages whose int header is even.
+func even(message interfaces.IPipeMessage, params interface{}) bool {
+	return message.Header().(int)%2 == 0
+}
+
+/*
+Test combining predicates with And, Or and Not.
+*/
+func TestPredicatesAndOrNot(t *testing.T) {
+	message4 := messages.NewMessage(messages.NORMAL, 4, nil, messages.PRIORITY_MED)
+	message7 := messages.NewMessage(messages.NORMAL, 7, nil, messages.PRIORITY_MED)
+	message2 := messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED)
+
+	and := plumbing.And(above, even)
+	if and(message4, 3) != true || and(message7, 3) != false || and(message2, 3) != false {
+		t.Error("Expecting And passes only messages both predicates pass")
+	}
+
+	or := plumbing.Or(above, even)
+	if or(message4, 3) != true || or(message7, 3) != true || or(message2, 3) != true || or(message2, 5) != true {
+		t.Error("Expecting Or passes messages either predicate passes")
+	}
+	if plumbing.Or(above, plumbing.Not(even))(message2, 3) != false {
+		t.Error("Expecting Or fails messages neither predicate passes")
+	}
+
+	if plumbing.Not(even)(message4, nil) != false || plumbing.Not(even)(message7, nil) != true {
+		t.Error("Expecting Not inverts the predicate")
+	}
+}
+
+/*
+Test combining predicates with Any and All, and that evaluation stops early.
+*/
+func TestPredicatesAnyAll(t *testing.T) {
+	message := messages.NewMessage(messages.NORMAL, 4, nil, messages.PRIORITY_MED)
+	evaluated := 0
+	counting := func(message interfaces.IPipeMessage, params interface{}) bool {
+		evaluated++
+		return true
+	}
+
+	if plumbing.All(even, plumbing.Not(even), counting)(message, nil) != false || evaluated != 0 {
+		t.Error("Expecting All fails at the first failing predicate")
+	}
+	if plumbing.Any(even, counting)(message, nil) != true || evaluated != 0 {
+		t.Error("Expecting Any passes at the first passing predicate")
+	}
+	if plumbing.All(counting, even, above)(message, 1) != true || evaluated != 1 {
+		t.Error("Expecting All passes when every predicate passes")
+	}
+
+	if plumbing.All()(message, nil) != true {
+		t.Error("Expecting All of no predicates passes")
+	}
+	if plumbing.Any()(message, nil) != false {
+		t.Error("Expecting Any of no predicates fails")
+	}
+
+	callback := Callback{}
+	filter := &plumbing.Filter{Name: "combined", Mode: messages.FILTER, Filter: plumbing.All(even, above), Params: 3,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	if filter.Write(message) != true {
+		t.Error("Expecting combined predicate usable as a Filter function")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
 	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
 	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"sync"
 	"testing"
 )
 
@@ -295,4 +296,244 @@ func TestSortByPriorityAndFIFO(t *testing.T) {
 	if received3Again.Priority() != messages.PRIORITY_HIGH {
 		t.Error("Expecting received3Again is priority high")
 	}
-}
\ No newline at end of file
+}
+/*
+Test inspecting the queue without removing messages.
+*/
+func TestQueueInspection(t *testing.T) {
+	queue := &plumbing.Queue{Pipe: plumbing.Pipe{Output: &plumbing.Pipe{}}}
+	for i := 1; i <= 3; i++ {
+		queue.Write(messages.NewMessage(messages.NORMAL, i, nil, messages.PRIORITY_MED))
+	}
+
+	if queue.Len() != 3 {
+		t.Error("Expecting 3 messages stored")
+	}
+	peeked := queue.Peek(2)
+	if len(peeked) != 2 || peeked[0].Header() != 1 || peeked[1].Header() != 2 {
+		t.Error("Expecting peeked the first 2 messages")
+	}
+	if len(queue.Peek(5)) != 3 || len(queue.Peek(-1)) != 0 {
+		t.Error("Expecting peek limited to the messages stored")
+	}
+
+	snapshot := queue.Snapshot()
+	snapshot[0] = nil
+	if len(snapshot) != 3 || queue.Peek(1)[0] == nil || queue.Len() != 3 {
+		t.Error("Expecting snapshot is a copy that leaves the queue unchanged")
+	}
+}
+
+/*
+Test releasing and discarding part of the queue by method and by control message.
+*/
+func TestQueueSelectiveDrain(t *testing.T) {
+	callback := Callback{}
+	queue := &plumbing.Queue{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	for i := 1; i <= 8; i++ {
+		queue.Write(messages.NewMessage(messages.NORMAL, i, nil, messages.PRIORITY_MED))
+	}
+	odd := func(message interfaces.IPipeMessage) bool { return message.Header().(int)%2 == 1 }
+
+	removed := queue.RemoveWhere(odd)
+	if len(removed) != 4 || removed[0].Header() != 1 || queue.Len() != 4 {
+		t.Error("Expecting odd messages removed")
+	}
+
+	if queue.FlushN(1) != true || len(callback.messagesReceived) != 1 || callback.messagesReceived[0].Header() != 2 {
+		t.Error("Expecting the first message flushed")
+	}
+
+	if queue.Write(messages.NewQueueCountControlMessage(messages.FLUSH_N, 1)) != true || callback.messagesReceived[1].Header() != 4 {
+		t.Error("Expecting FLUSH_N flushed the next message")
+	}
+
+	if queue.Write(messages.NewQueueSelectControlMessage(messages.FLUSH_WHERE, nil, `header > 6`)) != true {
+		t.Error("Expecting FLUSH_WHERE with an expression accepted")
+	}
+	if len(callback.messagesReceived) != 3 || callback.messagesReceived[2].Header() != 8 || queue.Len() != 1 {
+		t.Error("Expecting only the selected message flushed")
+	}
+
+	if queue.Write(messages.NewQueueSelectControlMessage(messages.FLUSH_WHERE, nil, `header >`)) != false {
+		t.Error("Expecting invalid expression rejected")
+	}
+	if queue.Write(messages.NewMessage(messages.FLUSH_N, nil, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting malformed control message rejected")
+	}
+
+	queue.Write(messages.NewMessage(messages.NORMAL, 9, nil, messages.PRIORITY_MED))
+	queue.Write(messages.NewQueueSelectControlMessage(messages.PURGE, odd, ""))
+	if queue.Len() != 1 || queue.Peek(1)[0].Header() != 6 {
+		t.Error("Expecting PURGE discarded the selected message")
+	}
+	queue.Write(messages.NewQueueControlMessage(messages.PURGE))
+	if queue.Len() != 0 || len(callback.messagesReceived) != 3 {
+		t.Error("Expecting PURGE without a selection discarded everything")
+	}
+}
+
+/*
+Test that the queue can be inspected and drained while other goroutines write.
+*/
+func TestQueueConcurrentDrain(t *testing.T) {
+	sink := &fuzzSink{}
+	queue := &plumbing.Queue{Pipe: plumbing.Pipe{Output: sink}}
+
+	var group sync.WaitGroup
+	for writer := 0; writer < 4; writer++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for i := 0; i < 100; i++ {
+				queue.Write(messages.NewMessage(messages.NORMAL, i, nil, messages.PRIORITY_MED))
+			}
+		}()
+	}
+	removed := 0
+	for i := 0; i < 50; i++ {
+		queue.Len()
+		queue.Snapshot()
+		queue.FlushN(2)
+		removed += len(queue.RemoveWhere(func(message interfaces.IPipeMessage) bool { return message.Header().(int) == 99 }))
+	}
+	group.Wait()
+	removed += len(queue.RemoveWhere(func(message interfaces.IPipeMessage) bool { return message.Header().(int) == 99 }))
+	queue.Flush()
+
+	if sink.received+removed != 400 || removed != 4 {
+		t.Error("Expecting every message either flushed or removed")
+	}
+}
+
+// recordingFitting An output recording what it was written, safe for concurrent writes.
+type recordingFitting struct {
+	plumbing.Pipe
+	messagesReceived []interfaces.IPipeMessage
+	mutex            sync.Mutex
+}
+
+func (r *recordingFitting) Write(message interfaces.IPipeMessage) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.messagesReceived = append(r.messagesReceived, message)
+	return true
+}
+
+/*
+Test that a resumed queue flushes its backlog, then passes messages through until paused.
+*/
+func TestQueuePauseResume(t *testing.T) {
+	callback := Callback{}
+	queue := &plumbing.Queue{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	queue.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_MED))
+	if queue.Paused() != true || len(callback.messagesReceived) != 0 {
+		t.Error("Expecting messages stored while paused")
+	}
+
+	if queue.Write(messages.NewQueueControlMessage(messages.RESUME)) != true {
+		t.Error("Expecting resume accepted")
+	}
+	if queue.Paused() != false || len(callback.messagesReceived) != 1 {
+		t.Error("Expecting backlog flushed on resume")
+	}
+
+	queue.Write(messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED))
+	if len(callback.messagesReceived) != 2 || callback.messagesReceived[1].Header() != 2 || queue.Len() != 0 {
+		t.Error("Expecting message passed straight through while resumed")
+	}
+
+	queue.Write(messages.NewQueueControlMessage(messages.PAUSE))
+	queue.Write(messages.NewMessage(messages.NORMAL, 3, nil, messages.PRIORITY_MED))
+	if queue.Paused() != true || len(callback.messagesReceived) != 2 || queue.Len() != 1 {
+		t.Error("Expecting message stored again once paused")
+	}
+}
+
+/*
+Test that no message overtakes an earlier one from the same writer across a resume.
+*/
+func TestQueueResumeOrdering(t *testing.T) {
+	output := &recordingFitting{}
+	queue := &plumbing.Queue{Pipe: plumbing.Pipe{Output: output}}
+
+	var group sync.WaitGroup
+	for writer := 0; writer < 4; writer++ {
+		group.Add(1)
+		go func(writer int) {
+			defer group.Done()
+			for i := 0; i < 200; i++ {
+				queue.Write(messages.NewMessage(messages.NORMAL, writer, i, messages.PRIORITY_MED))
+			}
+		}(writer)
+	}
+	queue.Resume()
+	group.Wait()
+
+	if len(output.messagesReceived) != 800 {
+		t.Fatal("Expecting every message delivered after resume")
+	}
+	last := map[int]int{0: -1, 1: -1, 2: -1, 3: -1}
+	for _, message := range output.messagesReceived {
+		writer, sequence := message.Header().(int), message.Body().(int)
+		if sequence != last[writer]+1 {
+			t.Fatal("Expecting messages of each writer delivered in order")
+		}
+		last[writer] = sequence
+	}
+}
+
+/*
+Test that queue control messages act on the queue they are addressed to, and pass through the others.
+*/
+func TestNamedQueues(t *testing.T) {
+	callback := Callback{}
+	priority := &plumbing.Queue{Name: "priority", Mode: messages.SORT,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	staging := &plumbing.Queue{Name: "staging", Pipe: plumbing.Pipe{Output: priority}}
+
+	staging.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_LOW))
+	staging.Write(messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_HIGH))
+
+	if staging.Write(messages.NewNamedQueueControlMessage(messages.FLUSH, "priority")) != true {
+		t.Error("Expecting flush addressed to the priority queue written through")
+	}
+	if staging.Len() != 2 || priority.Len() != 0 {
+		t.Error("Expecting the staging queue not flushed by a flush addressed to the priority queue")
+	}
+
+	staging.Write(messages.NewNamedQueueControlMessage(messages.FLUSH, "staging"))
+	if staging.Len() != 0 || priority.Len() != 2 || len(callback.messagesReceived) != 0 {
+		t.Error("Expecting the staging queue flushed into the priority queue")
+	}
+
+	staging.Write(messages.NewQueueControlMessage(messages.FLUSH))
+	if priority.Len() != 2 || len(callback.messagesReceived) != 1 || callback.messagesReceived[0].Type() != messages.FLUSH {
+		t.Error("Expecting an unaddressed flush written through named queues")
+	}
+
+	staging.Write(messages.NewNamedQueueControlMessage(messages.FLUSH, "priority"))
+	if len(callback.messagesReceived) != 3 || callback.messagesReceived[1].Header() != 2 {
+		t.Error("Expecting the priority queue flushed in priority order")
+	}
+}
+
+/*
+Test that an unnamed queue keeps acting on unaddressed messages, and passes addressed ones on.
+*/
+func TestUnnamedQueueAddressing(t *testing.T) {
+	callback := Callback{}
+	queue := &plumbing.Queue{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	queue.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_MED))
+	queue.Write(messages.NewNamedQueueControlMessage(messages.FLUSH, "other"))
+	if queue.Len() != 1 || len(callback.messagesReceived) != 1 || callback.messagesReceived[0].Type() != messages.FLUSH {
+		t.Error("Expecting a flush addressed to another queue written through")
+	}
+
+	queue.Write(messages.NewQueueControlMessage(messages.FLUSH))
+	if queue.Len() != 0 || len(callback.messagesReceived) != 2 {
+		t.Error("Expecting an unaddressed flush to flush the unnamed queue")
+	}
+}
@@ -0,0 +1,224 @@
+//
+//  Resequencer_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"strconv"
+	"testing"
+	"time"
+)
+
+/*
+Test the Resequencer class.
+*/
+
+/*
+Test that out of order messages are written in sequence.
+*/
+func TestResequencingOutOfOrderMessages(t *testing.T) {
+	callback := Callback{}
+	resequencer := &plumbing.Resequencer{First: 1,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for _, number := range []int{3, 1, 4, 2} {
+		if resequencer.Write(messages.NewMessage(messages.NORMAL, number, nil, messages.PRIORITY_MED)) != true {
+			t.Error("Expecting wrote message to resequencer")
+		}
+	}
+
+	if len(callback.messagesReceived) != 4 {
+		t.Fatal("Expecting received 4 messages")
+	}
+	for index, received := range callback.messagesReceived {
+		if received.Header().(int) != index+1 {
+			t.Error("Expecting messages received in sequence")
+		}
+	}
+
+	// late duplicate is dropped
+	if resequencer.Write(messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting late duplicate rejected")
+	}
+}
+
+/*
+Test that independent streams are sequenced separately.
+*/
+func TestResequencerStreams(t *testing.T) {
+	callback := Callback{}
+	resequencer := &plumbing.Resequencer{
+		Stream: func(message interfaces.IPipeMessage) string { return message.Body().(string) },
+		Pipe:   plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 1, "a", messages.PRIORITY_MED))
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "b", messages.PRIORITY_MED))
+	if len(callback.messagesReceived) != 1 || callback.messagesReceived[0].Body() != "b" {
+		t.Error("Expecting stream b written while stream a waits")
+	}
+
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "a", messages.PRIORITY_MED))
+	if len(callback.messagesReceived) != 3 {
+		t.Error("Expecting stream a released")
+	}
+}
+
+/*
+Test that a gap is skipped after the timeout with a gap notification.
+*/
+func TestResequencerGapNotification(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	resequencer := &plumbing.Resequencer{GapTimeout: time.Second, Mode: plumbing.GAP_NOTIFY, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 0, nil, messages.PRIORITY_MED))
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 3, nil, messages.PRIORITY_MED))
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 4, nil, messages.PRIORITY_MED))
+
+	clock.Advance(999 * time.Millisecond)
+	if len(callback.messagesReceived) != 1 {
+		t.Error("Expecting received 1 message before the gap timeout")
+	}
+
+	clock.Advance(time.Millisecond)
+	if len(callback.messagesReceived) != 4 {
+		t.Fatal("Expecting received 4 messages after the gap timeout")
+	}
+	gap, ok := callback.messagesReceived[1].(*messages.SequenceGapMessage)
+	if !ok {
+		t.Fatal("Expecting gap notification")
+	}
+	if gap.Type() != messages.GAP || gap.From() != 1 || gap.To() != 2 {
+		t.Error("Expecting gap from 1 to 2")
+	}
+	if callback.messagesReceived[2].Header().(int) != 3 || callback.messagesReceived[3].Header().(int) != 4 {
+		t.Error("Expecting 3 and 4 released after the gap")
+	}
+
+	// skipped numbers are dropped if they arrive late
+	if resequencer.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting skipped message rejected")
+	}
+}
+
+/*
+Test that a FLUSH message releases buffered messages in order.
+*/
+func TestResequencerFlush(t *testing.T) {
+	callback := Callback{}
+	resequencer := &plumbing.Resequencer{
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 5, nil, messages.PRIORITY_MED))
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED))
+	resequencer.Write(messages.NewQueueControlMessage(messages.FLUSH))
+
+	if len(callback.messagesReceived) != 3 {
+		t.Fatal("Expecting received 3 messages")
+	}
+	if callback.messagesReceived[0].Header().(int) != 2 || callback.messagesReceived[1].Header().(int) != 5 {
+		t.Error("Expecting buffered messages flushed in order")
+	}
+	if callback.messagesReceived[2].Type() != messages.FLUSH {
+		t.Error("Expecting flush message written through")
+	}
+}
+
+/*
+Test that an output can write to the resequencer it is written by, and that a blocked stream does not hold up others.
+*/
+func TestResequencerReentrantOutput(t *testing.T) {
+	var received []string
+	gate := make(chan struct{})
+	var resequencer *plumbing.Resequencer
+	output := &plumbing.PipeListener{Listener: func(message interfaces.IPipeMessage) {
+		stream, number := message.Body().(string), message.Header().(int)
+		if stream == "slow" {
+			<-gate
+			return
+		}
+		received = append(received, stream+strconv.Itoa(number))
+		if number < 2 { // acknowledge by writing the next message of the stream
+			resequencer.Write(messages.NewMessage(messages.NORMAL, number+1, stream, messages.PRIORITY_MED))
+		}
+	}}
+	resequencer = &plumbing.Resequencer{
+		Stream: func(message interfaces.IPipeMessage) string { return message.Body().(string) },
+		Pipe:   plumbing.Pipe{Output: output}}
+
+	blocked := make(chan bool)
+	go func() {
+		blocked <- resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "slow", messages.PRIORITY_MED))
+	}()
+
+	done := make(chan bool)
+	go func() {
+		done <- resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "fast", messages.PRIORITY_MED))
+	}()
+	select {
+	case success := <-done:
+		if success != true {
+			t.Error("Expecting the fast stream written")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expecting the fast stream neither blocked by the slow one nor deadlocked by its own output")
+	}
+	if equalStrings(received, []string{"fast0", "fast1", "fast2"}) != true {
+		t.Errorf("Expecting the messages written by the output in sequence, got %v", received)
+	}
+
+	close(gate)
+	if <-blocked != true {
+		t.Error("Expecting the slow stream written once its output returns")
+	}
+}
+
+/*
+Test that streams with nothing buffered are forgotten once idle for the stream timeout.
+*/
+func TestResequencerDropsIdleStreams(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	resequencer := &plumbing.Resequencer{StreamTimeout: time.Minute, Clock: clock,
+		Stream: func(message interfaces.IPipeMessage) string { return message.Body().(string) },
+		Pipe:   plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for session := 0; session < 100; session++ {
+		resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "session"+strconv.Itoa(session), messages.PRIORITY_MED))
+	}
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 1, "waiting", messages.PRIORITY_MED))
+	clock.Advance(59 * time.Second)
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "recent", messages.PRIORITY_MED))
+	clock.Advance(time.Second)
+
+	for session := 100; session < 200; session++ {
+		resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "session"+strconv.Itoa(session), messages.PRIORITY_MED))
+	}
+	if resequencer.Streams() > 110 {
+		t.Errorf("Expecting the idle streams dropped, got %d streams", resequencer.Streams())
+	}
+
+	if resequencer.Write(messages.NewMessage(messages.NORMAL, 1, "recent", messages.PRIORITY_MED)) != true ||
+		callback.messagesReceived[len(callback.messagesReceived)-1].Body() != "recent" {
+		t.Error("Expecting a stream idle for less than the timeout remembered")
+	}
+	if resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "session0", messages.PRIORITY_MED)) != true {
+		t.Error("Expecting a forgotten stream to start again")
+	}
+
+	// a stream with messages buffered is kept
+	resequencer.Write(messages.NewMessage(messages.NORMAL, 0, "waiting", messages.PRIORITY_MED))
+	last := callback.messagesReceived[len(callback.messagesReceived)-2:]
+	if last[0].Body() != "waiting" || last[0].Header() != 0 || last[1].Body() != "waiting" || last[1].Header() != 1 {
+		t.Error("Expecting the buffered message of an idle stream released, not lost")
+	}
+}
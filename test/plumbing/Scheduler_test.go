@@ -0,0 +1,273 @@
+//
+//  Scheduler_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the Scheduler class.
+*/
+
+// newScheduledMessage Create a normal message with a message ID and a metadata key set.
+func newScheduledMessage(id int, key string, value interface{}) interfaces.IPipeMessage {
+	message := messages.NewMessage(messages.NORMAL, id, nil, messages.PRIORITY_MED)
+	messages.SetMetadata(message, messages.METADATA_ID, id)
+	messages.SetMetadata(message, key, value)
+	return message
+}
+
+// receivedHeaders Get the int headers of the messages received.
+func receivedHeaders(received []interfaces.IPipeMessage) []int {
+	var headers []int
+	for _, message := range received {
+		headers = append(headers, message.Header().(int))
+	}
+	return headers
+}
+
+/*
+Test that delayed messages are delivered in order of when they are due.
+*/
+func TestSchedulerDelay(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	scheduler := &plumbing.Scheduler{Name: "scheduler", Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	scheduler.Write(newScheduledMessage(1, messages.METADATA_DELAY, 3*time.Second))
+	scheduler.Write(newScheduledMessage(2, messages.METADATA_DELAY, time.Second))
+	scheduler.Write(newScheduledMessage(3, messages.METADATA_DELAY, 3*time.Second))
+	scheduler.Write(newScheduledMessage(4, messages.METADATA_DELIVER_AT, clock.Now().Add(2*time.Second)))
+
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting no messages delivered before they are due")
+	}
+	if scheduler.Pending() != 4 {
+		t.Error("Expecting scheduler.Pending() == 4")
+	}
+
+	clock.Advance(time.Second)
+	if equalInts(receivedHeaders(callback.messagesReceived), []int{2}) != true {
+		t.Error("Expecting the message delayed by a second delivered after a second")
+	}
+
+	clock.Advance(2 * time.Second)
+	if equalInts(receivedHeaders(callback.messagesReceived), []int{2, 4, 1, 3}) != true {
+		t.Error("Expecting messages delivered in order due, then in order scheduled")
+	}
+	if scheduler.Pending() != 0 {
+		t.Error("Expecting scheduler.Pending() == 0")
+	}
+}
+
+/*
+Test that unscheduled and overdue messages are written straight through.
+*/
+func TestSchedulerPassThrough(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	scheduler := &plumbing.Scheduler{Name: "scheduler", Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	scheduler.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_MED))
+	scheduler.Write(newScheduledMessage(2, messages.METADATA_DELIVER_AT, clock.Now().Add(-time.Second)))
+	scheduler.Write(newScheduledMessage(3, messages.METADATA_DELAY, time.Duration(0)))
+
+	if equalInts(receivedHeaders(callback.messagesReceived), []int{1, 2, 3}) != true {
+		t.Error("Expecting all messages written straight through")
+	}
+	if scheduler.Pending() != 0 {
+		t.Error("Expecting scheduler.Pending() == 0")
+	}
+}
+
+/*
+Test cancelling scheduled messages with a control message.
+*/
+func TestSchedulerCancel(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	scheduler := &plumbing.Scheduler{Name: "scheduler", Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	scheduler.Write(newScheduledMessage(1, messages.METADATA_DELAY, time.Second))
+	scheduler.Write(newScheduledMessage(2, messages.METADATA_DELAY, 2*time.Second))
+	scheduler.Write(newScheduledMessage(3, messages.METADATA_DELAY, 3*time.Second))
+
+	if scheduler.Write(messages.NewSchedulerControlMessage(messages.CANCEL, "scheduler", "1")) != true {
+		t.Error("Expecting cancelling a scheduled message to succeed")
+	}
+	if scheduler.Write(messages.NewSchedulerControlMessage(messages.CANCEL, "scheduler", "1")) != false {
+		t.Error("Expecting cancelling an unscheduled message to fail")
+	}
+	if scheduler.Write(messages.NewSchedulerControlMessage(messages.CANCEL, "other", "3")) != true {
+		t.Error("Expecting a cancellation for another scheduler written through")
+	}
+	if len(callback.messagesReceived) != 1 || callback.messagesReceived[0].Type() != messages.CANCEL {
+		t.Error("Expecting only the cancellation for another scheduler received")
+	}
+	if scheduler.Write(messages.NewMessage(messages.CANCEL, nil, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting a malformed cancellation to fail")
+	}
+
+	clock.Advance(3 * time.Second)
+	if equalInts(receivedHeaders(callback.messagesReceived[1:]), []int{2, 3}) != true {
+		t.Error("Expecting the cancelled message never delivered")
+	}
+}
+
+/*
+Test cancelling by a custom message ID.
+*/
+func TestSchedulerCancelCustomID(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	scheduler := &plumbing.Scheduler{Name: "scheduler", Clock: clock,
+		ID:   func(message interfaces.IPipeMessage) string { return message.Body().(string) },
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for header, body := range []string{"a", "b", "a"} {
+		message := messages.NewMessage(messages.NORMAL, header, body, messages.PRIORITY_MED)
+		messages.SetMetadata(message, messages.METADATA_DELAY, time.Second)
+		scheduler.Write(message)
+	}
+
+	if scheduler.Cancel("a") != 2 {
+		t.Error("Expecting scheduler.Cancel(\"a\") == 2")
+	}
+	clock.Advance(time.Second)
+	if equalInts(receivedHeaders(callback.messagesReceived), []int{1}) != true {
+		t.Error("Expecting only the message with ID b delivered")
+	}
+}
+
+/*
+Test that a flush delivers all scheduled messages at once.
+*/
+func TestSchedulerFlush(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	scheduler := &plumbing.Scheduler{Name: "scheduler", Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	scheduler.Write(newScheduledMessage(1, messages.METADATA_DELAY, 2*time.Second))
+	scheduler.Write(newScheduledMessage(2, messages.METADATA_DELAY, time.Second))
+
+	if scheduler.Write(messages.NewQueueControlMessage(messages.FLUSH)) != true {
+		t.Error("Expecting the flush to succeed")
+	}
+	if len(callback.messagesReceived) != 3 || callback.messagesReceived[2].Type() != messages.FLUSH {
+		t.Error("Expecting the scheduled messages, then the flush received")
+	}
+	if equalInts(receivedHeaders(callback.messagesReceived[:2]), []int{2, 1}) != true {
+		t.Error("Expecting the scheduled messages flushed in order due")
+	}
+
+	clock.Advance(time.Hour)
+	if len(callback.messagesReceived) != 3 {
+		t.Error("Expecting no messages delivered twice")
+	}
+}
+
+/*
+Test the status of a scheduler.
+*/
+func TestSchedulerStatus(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	scheduler := &plumbing.Scheduler{Name: "scheduler", Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	scheduler.Write(newScheduledMessage(1, messages.METADATA_DELAY, time.Second))
+	scheduler.Write(messages.NewStatusMessage("scheduler"))
+
+	records := callback.messagesReceived[0].(*messages.StatusMessage).Records()
+	if len(records) != 1 || records[0].Fitting != "Scheduler" || records[0].State["pending"] != 1 {
+		t.Error("Expecting a status record with one pending message")
+	}
+	if records[0].State["next"] != clock.Now().Add(time.Second) {
+		t.Error("Expecting the status record to show when the next message is due")
+	}
+}
+
+/*
+Test that a cancellation without a message ID cancels nothing.
+*/
+func TestSchedulerCancelEmptyID(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	scheduler := &plumbing.Scheduler{Name: "scheduler", Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for id := 1; id <= 3; id++ {
+		message := messages.NewMessage(messages.NORMAL, id, nil, messages.PRIORITY_MED)
+		messages.SetMetadata(message, messages.METADATA_DELAY, time.Second)
+		scheduler.Write(message)
+	}
+
+	if scheduler.Write(messages.NewSchedulerControlMessage(messages.CANCEL, "scheduler", "")) != false {
+		t.Error("Expecting a cancellation without a message ID to fail")
+	}
+	if scheduler.Cancel("") != 0 || scheduler.Pending() != 3 {
+		t.Error("Expecting the messages without a message ID still scheduled")
+	}
+
+	clock.Advance(time.Second)
+	if equalInts(receivedHeaders(callback.messagesReceived), []int{1, 2, 3}) != true {
+		t.Error("Expecting the messages without a message ID delivered")
+	}
+}
+
+/*
+Test that an output can schedule messages on the scheduler it is written by.
+*/
+func TestSchedulerReschedule(t *testing.T) {
+	clock := NewFakeClock()
+	var received []int
+	var scheduler *plumbing.Scheduler
+	retry := &plumbing.PipeListener{Listener: func(message interfaces.IPipeMessage) {
+		header := message.Header().(int)
+		received = append(received, header)
+		if header < 3 { // retry as the next attempt, after a millisecond, then at once
+			delay := time.Millisecond
+			if header == 2 {
+				delay = 0
+			}
+			scheduler.Write(newScheduledMessage(header+1, messages.METADATA_DELAY, delay))
+			scheduler.Write(newScheduledMessage(header+10, messages.METADATA_DELAY, delay))
+		}
+	}}
+	scheduler = &plumbing.Scheduler{Name: "scheduler", Clock: clock, Pipe: plumbing.Pipe{Output: retry}}
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Write(newScheduledMessage(1, messages.METADATA_DELAY, time.Millisecond))
+		clock.Advance(time.Millisecond)
+		clock.Advance(time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expecting rescheduling from the output not to deadlock")
+	}
+
+	if equalInts(received, []int{1, 2, 11, 3, 12}) != true {
+		t.Errorf("Expecting the retries delivered in order they were scheduled, got %v", received)
+	}
+	if scheduler.Pending() != 0 {
+		t.Error("Expecting scheduler.Pending() == 0")
+	}
+}
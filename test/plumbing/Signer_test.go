@@ -0,0 +1,195 @@
+//
+//  Signer_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the Signer class.
+*/
+
+var testKeys = plumbing.StaticKeys{"2019-01": []byte("first secret"), "2019-02": []byte("second secret")}
+
+// bareMessage A normal message without metadata.
+type bareMessage struct{}
+
+func (m *bareMessage) Type() string          { return messages.NORMAL }
+func (m *bareMessage) SetType(string)        {}
+func (m *bareMessage) Priority() int         { return messages.PRIORITY_MED }
+func (m *bareMessage) SetPriority(int)       {}
+func (m *bareMessage) Header() interface{}   { return nil }
+func (m *bareMessage) SetHeader(interface{}) {}
+func (m *bareMessage) Body() interface{}     { return nil }
+func (m *bareMessage) SetBody(interface{})   {}
+
+/*
+Test that a signer stores the signature and key ID of normal messages in their metadata.
+*/
+func TestSignerSignsNormalMessages(t *testing.T) {
+	output := &FailingFitting{}
+	signer := &plumbing.Signer{Name: "signer", Keys: testKeys, KeyID: "2019-01", Pipe: plumbing.Pipe{Output: output}}
+
+	message := messages.NewMessage(messages.NORMAL, map[string]interface{}{"b": 2, "a": 1}, "body", messages.PRIORITY_HIGH)
+	if signer.Write(message) != true || len(output.messagesReceived) != 1 {
+		t.Fatal("Expecting the signed message written")
+	}
+	signed := output.messagesReceived[0]
+	if signature, ok := messages.Metadata(signed, messages.METADATA_SIGNATURE).(string); !ok || signature == "" {
+		t.Error("Expecting the signature in the metadata")
+	}
+	if messages.Metadata(signed, messages.METADATA_SIGNATURE_KEY_ID) != "2019-01" {
+		t.Error("Expecting the key ID in the metadata")
+	}
+	if signed == message || messages.Metadata(message, messages.METADATA_SIGNATURE) != nil {
+		t.Error("Expecting a copy signed, leaving the message written unchanged")
+	}
+
+	signer.Write(messages.NewMessage(messages.NORMAL, map[string]interface{}{"a": 1, "b": 2}, "body", messages.PRIORITY_HIGH))
+	if messages.Metadata(output.messagesReceived[1], messages.METADATA_SIGNATURE) != messages.Metadata(signed, messages.METADATA_SIGNATURE) {
+		t.Error("Expecting equal messages to have equal signatures")
+	}
+
+	flush := messages.NewQueueControlMessage(messages.FLUSH)
+	if signer.Write(flush) != true || output.messagesReceived[2] != flush || messages.Metadata(flush, messages.METADATA_SIGNATURE) != nil {
+		t.Error("Expecting control messages written through unsigned")
+	}
+}
+
+type signedHeader struct {
+	Kind  string
+	Count int
+	Tags  []string `json:"tags"`
+}
+
+type secretBody struct{ amount int }
+
+type partlySecretBody struct {
+	Amount int
+	Note   string `json:"-"`
+}
+
+type secretAmount int
+
+type embeddedSecretBody struct{ secretAmount }
+
+/*
+Test that the signature does not depend on whether a value is a struct or a map, and covers the claimed sender.
+*/
+func TestSignerCanonicalSerialization(t *testing.T) {
+	signer := &plumbing.Signer{Name: "signer", Keys: testKeys, KeyID: "2019-01"}
+	verifier := &plumbing.Verifier{Name: "verifier", Keys: testKeys}
+
+	message := messages.NewMessage(messages.NORMAL, signedHeader{Kind: "order", Count: 3, Tags: []string{"a"}}, nil, messages.PRIORITY_MED)
+	messages.SetMetadata(message, messages.METADATA_SENDER, "core")
+	if signer.Sign(message) != nil {
+		t.Fatal("Expecting the message signed")
+	}
+
+	// the header rebuilt as a map, as on the other side of a process boundary
+	rebuilt := messages.NewMessage(messages.NORMAL, map[string]interface{}{"tags": []interface{}{"a"}, "Count": 3.0, "Kind": "order"}, nil, messages.PRIORITY_MED)
+	for _, key := range []string{messages.METADATA_SIGNATURE, messages.METADATA_SIGNATURE_KEY_ID, messages.METADATA_SENDER} {
+		messages.SetMetadata(rebuilt, key, messages.Metadata(message, key))
+	}
+	if verifier.Verify(rebuilt) != nil {
+		t.Error("Expecting the rebuilt message verified")
+	}
+
+	messages.SetMetadata(rebuilt, messages.METADATA_SENDER, "plugin")
+	if verifier.Verify(rebuilt) == nil {
+		t.Error("Expecting a changed sender rejected")
+	}
+
+	for _, body := range []interface{}{secretBody{amount: 1}, &partlySecretBody{Amount: 1, Note: "x"}, []interface{}{embeddedSecretBody{1}}} {
+		if signer.Sign(messages.NewMessage(messages.NORMAL, nil, body, messages.PRIORITY_MED)) == nil {
+			t.Errorf("Expecting a body with fields JSON leaves out refused: %#v", body)
+		}
+	}
+}
+
+type bytesHeader struct {
+	Raw  []byte `json:"raw"`
+	Text string
+}
+
+/*
+Test that a byte slice and its base64 string sign differently, wherever they are.
+*/
+func TestSignerByteSlices(t *testing.T) {
+	signer := &plumbing.Signer{Name: "signer", Keys: testKeys, KeyID: "2019-01"}
+	verifier := &plumbing.Verifier{Name: "verifier", Keys: testKeys}
+
+	// resign copies the signature of a message onto another with a header and body
+	resign := func(signed interfaces.IPipeMessage, header interface{}, body interface{}) interfaces.IPipeMessage {
+		message := messages.NewMessage(messages.NORMAL, header, body, messages.PRIORITY_MED)
+		for _, key := range []string{messages.METADATA_SIGNATURE, messages.METADATA_SIGNATURE_KEY_ID} {
+			messages.SetMetadata(message, key, messages.Metadata(signed, key))
+		}
+		return message
+	}
+
+	bytesBody := messages.NewMessage(messages.NORMAL, nil, []byte("abc"), messages.PRIORITY_MED)
+	stringBody := messages.NewMessage(messages.NORMAL, nil, "YWJj", messages.PRIORITY_MED)
+	if signer.Sign(bytesBody) != nil || signer.Sign(stringBody) != nil {
+		t.Fatal("Expecting the messages signed")
+	}
+	if verifier.Verify(resign(bytesBody, nil, []byte("abc"))) != nil {
+		t.Error("Expecting a byte slice body verified")
+	}
+	if verifier.Verify(resign(bytesBody, nil, "YWJj")) == nil {
+		t.Error("Expecting a byte slice body retyped as its base64 string rejected")
+	}
+	if verifier.Verify(resign(stringBody, nil, []byte("abc"))) == nil {
+		t.Error("Expecting a string body retyped as the bytes it decodes to rejected")
+	}
+
+	header := messages.NewMessage(messages.NORMAL, bytesHeader{Raw: []byte("abc"), Text: "YWJj"}, nil, messages.PRIORITY_MED)
+	if signer.Sign(header) != nil {
+		t.Fatal("Expecting the message signed")
+	}
+	if verifier.Verify(resign(header, map[string]interface{}{"raw": []byte("abc"), "Text": "YWJj"}, nil)) != nil {
+		t.Error("Expecting the header rebuilt as a map with the same byte slice verified")
+	}
+	if verifier.Verify(resign(header, map[string]interface{}{"raw": "YWJj", "Text": []byte("abc")}, nil)) == nil {
+		t.Error("Expecting a header with the byte slice moved to another field rejected")
+	}
+}
+
+/*
+Test that messages that cannot be signed are not written.
+*/
+func TestSignerFailures(t *testing.T) {
+	output := &FailingFitting{}
+	signer := &plumbing.Signer{Name: "signer", Keys: testKeys, KeyID: "unknown", Pipe: plumbing.Pipe{Output: output}}
+
+	if signer.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting a message not signed with an unknown key")
+	}
+
+	signer.Rotate("2019-02")
+	if signer.Write(messages.NewMessage(messages.NORMAL, nil, func() {}, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting a message with a body that cannot be encoded not signed")
+	}
+	if signer.Write(&bareMessage{}) != false {
+		t.Error("Expecting a message without metadata not signed")
+	}
+	if len(output.messagesReceived) != 0 {
+		t.Error("Expecting no unsigned message written")
+	}
+
+	signer.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	status := signer.Status()
+	if status["keyID"] != "2019-02" || status["signed"] != 1 || status["failed"] != 3 {
+		t.Error("Expecting the status to count signed and failed messages")
+	}
+}
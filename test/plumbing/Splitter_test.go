@@ -0,0 +1,61 @@
+//
+//  Splitter_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the Splitter class.
+*/
+
+/*
+Test that an aggregated batch is expanded back into individual messages.
+*/
+func TestSplittingAggregatedBatch(t *testing.T) {
+	callback := Callback{}
+	splitter := &plumbing.Splitter{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	aggregator := &plumbing.Aggregator{MaxCount: 2, Pipe: plumbing.Pipe{Output: splitter}}
+
+	message1 := messages.NewMessage(messages.NORMAL, nil, 1, messages.PRIORITY_MED)
+	message2 := messages.NewMessage(messages.NORMAL, nil, 2, messages.PRIORITY_MED)
+
+	aggregator.Write(message1)
+	written := aggregator.Write(message2)
+
+	if written != true {
+		t.Error("Expecting wrote batch through the splitter")
+	}
+	if len(callback.messagesReceived) != 2 {
+		t.Fatal("Expecting received 2 messages")
+	}
+	if callback.messagesReceived[0] != message1 || callback.messagesReceived[1] != message2 {
+		t.Error("Expecting received the original messages in order")
+	}
+}
+
+/*
+Test that messages which are not batches are written through unchanged.
+*/
+func TestSplitterWritesThroughOtherMessages(t *testing.T) {
+	callback := Callback{}
+	splitter := &plumbing.Splitter{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	message := messages.NewMessage(messages.NORMAL, nil, []int{1, 2}, messages.PRIORITY_MED)
+	flush := messages.NewQueueControlMessage(messages.FLUSH)
+	splitter.Write(message)
+	splitter.Write(flush)
+
+	if len(callback.messagesReceived) != 2 || callback.messagesReceived[0] != message || callback.messagesReceived[1] != flush {
+		t.Error("Expecting received both messages unchanged")
+	}
+}
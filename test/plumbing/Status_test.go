@@ -0,0 +1,109 @@
+//
+//  Status_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the status query protocol.
+*/
+
+/*
+Test that a status query collects the records of the fittings it is addressed to and reaches the terminal listener.
+*/
+func TestStatusQuery(t *testing.T) {
+	callback := Callback{}
+	filter := &plumbing.Filter{Name: "filter", Mode: messages.FILTER, Params: 3,
+		Filter: func(message interfaces.IPipeMessage, params interface{}) bool { return true }}
+	breaker := &plumbing.CircuitBreaker{Name: "breaker"}
+	queue := &plumbing.Queue{Mode: messages.SORT}
+	filter.Connect(breaker)
+	breaker.Connect(queue)
+	queue.Connect(&plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod})
+
+	filter.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	filter.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	// a query addressed to all fittings
+	query := messages.NewStatusMessage("")
+	if filter.Write(query) != true {
+		t.Error("Expecting query written through the pipeline")
+	}
+	if len(callback.messagesReceived) != 1 || callback.messagesReceived[0] != query {
+		t.Fatal("Expecting the query delivered to the terminal listener ahead of queued messages")
+	}
+	records := query.Records()
+	if len(records) != 3 {
+		t.Fatal("Expecting a record from each fitting")
+	}
+	if records[0].Name != "filter" || records[0].Fitting != "Filter" || records[0].State["mode"] != messages.FILTER || records[0].State["params"] != 3 {
+		t.Error("Expecting the filter reported its mode and parameters")
+	}
+	if records[1].Name != "breaker" || records[1].State["state"] != plumbing.CIRCUIT_CLOSED {
+		t.Error("Expecting the breaker reported its state")
+	}
+	if records[2].Fitting != "Queue" || records[2].State["mode"] != messages.SORT || records[2].State["length"] != 2 {
+		t.Error("Expecting the queue reported its mode and length")
+	}
+
+	// a query addressed by name
+	named := messages.NewStatusMessage("breaker")
+	filter.Write(named)
+	records = named.Records()
+	if len(records) != 1 || records[0].Name != "breaker" {
+		t.Error("Expecting only the named fitting reported")
+	}
+	if len(callback.messagesReceived) != 2 || callback.messagesReceived[1] != named {
+		t.Error("Expecting the named query delivered to the terminal listener")
+	}
+}
+
+/*
+Test that the chain and the time-based fittings report their state.
+*/
+func TestStatusQueryFittings(t *testing.T) {
+	callback := Callback{}
+	chain := &plumbing.FilterChain{Name: "chain"}
+	chain.Add("even", even, nil)
+	chain.Add("above", above, 1)
+	chain.SetMode("above", messages.BYPASS)
+	throttle := &plumbing.Throttle{Name: "throttle", Rate: 2, Burst: 4, Clock: NewFakeClock()}
+	transformer := &plumbing.Transformer{Name: "transformer", Params: "params"}
+	window := &plumbing.Window{Name: "window", Kind: plumbing.WINDOW_SESSION, Clock: NewFakeClock()}
+	chain.Connect(throttle)
+	throttle.Connect(transformer)
+	transformer.Connect(window)
+	window.Connect(&plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod})
+
+	query := messages.NewStatusMessage("")
+	chain.Write(query)
+	records := query.Records()
+	if len(records) != 4 {
+		t.Fatal("Expecting a record from each fitting")
+	}
+	predicates := records[0].State["predicates"].([]string)
+	bypassed := records[0].State["bypassed"].([]string)
+	if len(predicates) != 2 || len(bypassed) != 1 || bypassed[0] != "above" {
+		t.Error("Expecting the chain reported its predicates")
+	}
+	if records[1].State["rate"] != 2.0 || records[1].State["burst"] != 4 || records[1].State["queued"] != 0 {
+		t.Error("Expecting the throttle reported its rate")
+	}
+	if records[2].State["params"] != "params" {
+		t.Error("Expecting the transformer reported its parameters")
+	}
+	if records[3].State["kind"] != plumbing.WINDOW_SESSION || records[3].State["open"] != 0 {
+		t.Error("Expecting the window reported its kind")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
 	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
 	"testing"
+	"time"
 )
 
 /*
@@ -356,3 +357,140 @@ func TestReceiveMessagesFromFourPipesViaTeeMerge(t *testing.T) {
 		t.Error("Expecting message4.Header().(*Test).testVal != 4")
 	}
 }
+
+/*
+  Test listing and disconnecting inputs of a TeeMerge.
+*/
+func TestTeeMergeInputs(t *testing.T) {
+	pipe1 := &plumbing.Pipe{}
+	pipe2 := &plumbing.Pipe{}
+	split := &plumbing.TeeSplit{}
+
+	callback := Callback{}
+	teeMerge := &plumbing.TeeMerge{Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	teeMerge.ConnectInput(pipe1)
+	teeMerge.ConnectInput(pipe2)
+	teeMerge.ConnectInput(split)
+
+	inputs := teeMerge.Inputs()
+	if len(inputs) != 3 || inputs[0] != pipe1 || inputs[1] != pipe2 || inputs[2] != split {
+		t.Error("Expecting inputs pipe1, pipe2 and split")
+	}
+
+	if teeMerge.DisconnectInput(pipe1) != pipe1 {
+		t.Error("Expecting disconnected pipe1")
+	}
+	if pipe1.Output != nil {
+		t.Error("Expecting pipe1 output disconnected")
+	}
+	if teeMerge.DisconnectInput(pipe1) != nil {
+		t.Error("Expecting pipe1 no longer connected")
+	}
+
+	// a tee input keeps its other outputs
+	other := &plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}
+	split.Connect(other)
+	if teeMerge.DisconnectInput(split) != split {
+		t.Error("Expecting disconnected split")
+	}
+	split.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	if len(callback.messagesReceived) != 1 {
+		t.Error("Expecting split still writes to its other output")
+	}
+
+	if len(teeMerge.Inputs()) != 1 || teeMerge.Inputs()[0] != pipe2 {
+		t.Error("Expecting only pipe2 remains")
+	}
+}
+
+/*
+  Test stamping the source label of merged messages.
+*/
+func TestTeeMergeStampSource(t *testing.T) {
+	pipe1 := &plumbing.Pipe{}
+	pipe2 := &plumbing.Pipe{}
+
+	callback := Callback{}
+	teeMerge := &plumbing.TeeMerge{StampSource: true, Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+	teeMerge.ConnectLabeledInput(pipe1, "shell")
+	teeMerge.ConnectLabeledInput(pipe2, "logger")
+
+	pipe1.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	pipe2.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	if len(callback.messagesReceived) != 2 {
+		t.Fatal("Expecting received 2 messages")
+	}
+	if messages.Metadata(callback.messagesReceived[0], messages.METADATA_SOURCE) != "shell" {
+		t.Error("Expecting message 1 source == shell")
+	}
+	if messages.Metadata(callback.messagesReceived[1], messages.METADATA_SOURCE) != "logger" {
+		t.Error("Expecting message 2 source == logger")
+	}
+}
+
+/*
+  Test that merges fed by a parallel split stamp their own copies of a shared message.
+*/
+func TestTeeMergeStampSharedMessage(t *testing.T) {
+	split := &plumbing.ParallelSplit{}
+	var outputs []*gatedFitting
+	for _, label := range []string{"left", "right"} {
+		pipe := &plumbing.Pipe{}
+		output := &gatedFitting{gate: make(chan struct{})}
+		close(output.gate)
+		merge := &plumbing.TeeMerge{StampSource: true, Pipe: plumbing.Pipe{Output: output}}
+		merge.ConnectLabeledInput(pipe, label)
+		split.Connect(pipe)
+		outputs = append(outputs, output)
+	}
+
+	message := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	for i := 0; i < 100; i++ {
+		split.Write(message)
+	}
+
+	for index, label := range []string{"left", "right"} {
+		for _, received := range outputs[index].received() {
+			if messages.Metadata(received, messages.METADATA_SOURCE) != label {
+				t.Fatal("Expecting each merge to stamp its own label, got", messages.Metadata(received, messages.METADATA_SOURCE))
+			}
+		}
+	}
+	if messages.Metadata(message, messages.METADATA_SOURCE) != nil {
+		t.Error("Expecting the shared message not stamped")
+	}
+}
+
+/*
+  Test that async mode interleaves inputs fairly.
+*/
+func TestTeeMergeAsyncFairness(t *testing.T) {
+	chatty := &plumbing.Pipe{}
+	quiet := &plumbing.Pipe{}
+
+	output := &gatedFitting{gate: make(chan struct{})}
+	teeMerge := &plumbing.TeeMerge{Async: true, Backlog: 10, StampSource: true, Pipe: plumbing.Pipe{Output: output}}
+	teeMerge.ConnectLabeledInput(chatty, "chatty")
+	teeMerge.ConnectLabeledInput(quiet, "quiet")
+
+	for i := 0; i < 4; i++ {
+		if chatty.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != true {
+			t.Error("Expecting wrote chatty message")
+		}
+	}
+	quiet.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	close(output.gate)
+
+	deadline := time.Now().Add(time.Second)
+	for len(output.received()) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	received := output.received()
+	if len(received) != 5 {
+		t.Fatal("Expecting received 5 messages")
+	}
+	if messages.Metadata(received[1], messages.METADATA_SOURCE) != "quiet" {
+		t.Error("Expecting quiet message interleaved second")
+	}
+}
@@ -222,3 +222,19 @@ func TestReceiveMessagesFromTwoTeeSplitOutputs(t *testing.T) {
 		t.Error("Expecting message2 == message")
 	}
 }
+
+/*
+Test that disconnecting from a tee without outputs returns nil.
+*/
+func TestDisconnectEmptyTeeSplit(t *testing.T) {
+	teeSplit := &plumbing.TeeSplit{}
+	if teeSplit.Disconnect() != nil {
+		t.Error("Expecting nil disconnected from an empty tee")
+	}
+
+	pipe1 := &plumbing.Pipe{}
+	teeSplit.Connect(pipe1)
+	if teeSplit.Disconnect() != pipe1 || teeSplit.Disconnect() != nil {
+		t.Error("Expecting nil once all outputs are disconnected")
+	}
+}
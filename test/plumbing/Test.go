@@ -8,7 +8,12 @@
 
 package plumbing
 
-import "github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"sync"
+	"time"
+)
 
 type Callback struct {
 	messagesReceived []interfaces.IPipeMessage // Array of received messages.
@@ -43,3 +48,81 @@ type Bozo struct {
 type BozoThreshold struct {
 	level int
 }
+
+// FakeClock is a manually advanced plumbing.Clock, scheduled calls fire synchronously on Advance.
+type FakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+	mutex  sync.Mutex
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	f     func()
+	done  bool
+}
+
+func NewFakeClock() *FakeClock {
+	return &FakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(duration time.Duration, f func()) plumbing.Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timer := &fakeTimer{clock: c, at: c.now.Add(duration), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Advance moves the clock forward, firing due calls in time order.
+func (c *FakeClock) Advance(duration time.Duration) {
+	c.mutex.Lock()
+	target := c.now.Add(duration)
+	for {
+		var next *fakeTimer
+		for _, timer := range c.timers {
+			if !timer.done && !timer.at.After(target) && (next == nil || timer.at.Before(next.at)) {
+				next = timer
+			}
+		}
+		if next == nil {
+			break
+		}
+		next.done = true
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		c.mutex.Unlock()
+		next.f()
+		c.mutex.Lock()
+	}
+	c.now = target
+	c.mutex.Unlock()
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	stopped := !t.done
+	t.done = true
+	return stopped
+}
+
+// FailingFitting is an output that fails while Failing is true, recording what it was written.
+type FailingFitting struct {
+	plumbing.Pipe
+	Failing          bool
+	messagesReceived []interfaces.IPipeMessage
+}
+
+func (f *FailingFitting) Write(message interfaces.IPipeMessage) bool {
+	f.messagesReceived = append(f.messagesReceived, message)
+	return !f.Failing
+}
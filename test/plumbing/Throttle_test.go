@@ -0,0 +1,228 @@
+//
+//  Throttle_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the Throttle class.
+*/
+
+/*
+Test that messages in excess of the burst are dropped until tokens refill.
+*/
+func TestThrottleDrop(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	throttle := &plumbing.Throttle{Name: "status", Rate: 10, Burst: 2, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	written1 := throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	written2 := throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	written3 := throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	if written1 != true || written2 != true {
+		t.Error("Expecting burst of 2 messages written")
+	}
+	if written3 != false {
+		t.Error("Expecting third message dropped")
+	}
+
+	// one token refills after 100ms
+	clock.Advance(100 * time.Millisecond)
+	written4 := throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	written5 := throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	if written4 != true {
+		t.Error("Expecting message written after refill")
+	}
+	if written5 != false {
+		t.Error("Expecting message dropped after refill used")
+	}
+	if len(callback.messagesReceived) != 3 {
+		t.Error("Expecting received 3 messages")
+	}
+
+	// control messages are never throttled
+	flushWritten := throttle.Write(messages.NewQueueControlMessage(messages.FLUSH))
+	if flushWritten != true || len(callback.messagesReceived) != 4 {
+		t.Error("Expecting flush message written through")
+	}
+}
+
+/*
+Test that each key gets its own bucket.
+*/
+func TestThrottlePerKey(t *testing.T) {
+	callback := Callback{}
+	throttle := &plumbing.Throttle{Name: "status", Rate: 1, Burst: 1, Clock: NewFakeClock(),
+		Key:  func(message interfaces.IPipeMessage) string { return message.Header().(string) },
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	writtenA1 := throttle.Write(messages.NewMessage(messages.NORMAL, "a", nil, messages.PRIORITY_MED))
+	writtenA2 := throttle.Write(messages.NewMessage(messages.NORMAL, "a", nil, messages.PRIORITY_MED))
+	writtenB1 := throttle.Write(messages.NewMessage(messages.NORMAL, "b", nil, messages.PRIORITY_MED))
+
+	if writtenA1 != true || writtenB1 != true {
+		t.Error("Expecting first message of each key written")
+	}
+	if writtenA2 != false {
+		t.Error("Expecting second message of key a dropped")
+	}
+}
+
+/*
+Test that queued messages are released in order as the rate allows.
+*/
+func TestThrottleQueue(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	throttle := &plumbing.Throttle{Name: "status", Rate: 10, Burst: 1, Mode: plumbing.THROTTLE_QUEUE, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	message1 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	message2 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	message3 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	written1 := throttle.Write(message1)
+	written2 := throttle.Write(message2)
+	written3 := throttle.Write(message3)
+
+	if written1 != true || written2 != true || written3 != true {
+		t.Error("Expecting all messages accepted")
+	}
+	if len(callback.messagesReceived) != 1 {
+		t.Error("Expecting received 1 message before refill")
+	}
+
+	clock.Advance(100 * time.Millisecond)
+	if len(callback.messagesReceived) != 2 {
+		t.Error("Expecting received 2 messages after 100ms")
+	}
+
+	clock.Advance(100 * time.Millisecond)
+	if len(callback.messagesReceived) != 3 {
+		t.Fatal("Expecting received 3 messages after 200ms")
+	}
+	if callback.messagesReceived[0] != message1 || callback.messagesReceived[1] != message2 || callback.messagesReceived[2] != message3 {
+		t.Error("Expecting messages received in order")
+	}
+}
+
+/*
+Test that delay mode blocks the writer instead of dropping.
+*/
+func TestThrottleDelay(t *testing.T) {
+	callback := Callback{}
+	throttle := &plumbing.Throttle{Name: "status", Rate: 100, Burst: 1, Mode: plumbing.THROTTLE_DELAY,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != true {
+			t.Error("Expecting delayed message written")
+		}
+	}
+
+	if len(callback.messagesReceived) != 3 {
+		t.Error("Expecting received 3 messages")
+	}
+	if time.Since(start) < 15*time.Millisecond {
+		t.Error("Expecting writer was delayed")
+	}
+}
+
+/*
+Test retuning the rate with a control message.
+*/
+func TestThrottleSetRateByControlMessage(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	throttle := &plumbing.Throttle{Name: "status", Rate: 1, Burst: 1, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	// addressed to another throttle, written through
+	otherWritten := throttle.Write(messages.NewThrottleControlMessage(messages.SET_RATE, "other", 100, 5))
+	if otherWritten != true || len(callback.messagesReceived) != 2 {
+		t.Error("Expecting control message for another throttle written through")
+	}
+
+	setRateWritten := throttle.Write(messages.NewThrottleControlMessage(messages.SET_RATE, "status", 100, 5))
+	if setRateWritten != true {
+		t.Error("Expecting wrote set rate message")
+	}
+	if throttle.Rate != 100 || throttle.Burst != 5 {
+		t.Error("Expecting rate 100 and burst 5")
+	}
+
+	clock.Advance(10 * time.Millisecond)
+	if throttle.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != true {
+		t.Error("Expecting message written at the new rate")
+	}
+}
+
+/*
+Test that keys whose bucket has refilled are dropped as new keys arrive.
+*/
+func TestThrottleDropsIdleKeys(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	throttle := &plumbing.Throttle{Name: "status", Rate: 10, Burst: 1, Mode: plumbing.THROTTLE_QUEUE, Clock: clock,
+		Key:  func(message interfaces.IPipeMessage) string { return message.Header().(string) },
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for _, key := range []string{"a", "b", "c", "d", "d"} {
+		throttle.Write(messages.NewMessage(messages.NORMAL, key, nil, messages.PRIORITY_MED))
+	}
+	if throttle.Status()["keys"] != 4 {
+		t.Error("Expecting four keys tracked")
+	}
+
+	clock.Advance(time.Second)
+	throttle.Write(messages.NewMessage(messages.NORMAL, "e", nil, messages.PRIORITY_MED))
+	if throttle.Status()["keys"] != 1 {
+		t.Error("Expecting the idle keys dropped when a new key arrives")
+	}
+	if throttle.Write(messages.NewMessage(messages.NORMAL, "a", nil, messages.PRIORITY_MED)) != true || len(callback.messagesReceived) != 7 {
+		t.Error("Expecting a dropped key to start again with a full bucket")
+	}
+}
+
+/*
+Test that an output writing back into the throttle does not deadlock it.
+*/
+func TestThrottleReentrantOutput(t *testing.T) {
+	clock := NewFakeClock()
+	callback := &Callback{}
+	throttle := &plumbing.Throttle{Name: "status", Rate: 10, Burst: 1, Mode: plumbing.THROTTLE_QUEUE, Clock: clock}
+	throttle.Output = &plumbing.PipeListener{Listener: func(message interfaces.IPipeMessage) {
+		callback.CallbackMethod(message)
+		if message.Header() == 1 {
+			throttle.Write(messages.NewMessage(messages.NORMAL, 2, nil, messages.PRIORITY_MED))
+		}
+	}}
+
+	throttle.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_MED))
+	throttle.Write(messages.NewMessage(messages.NORMAL, 1, nil, messages.PRIORITY_MED))
+	clock.Advance(100 * time.Millisecond)
+	clock.Advance(100 * time.Millisecond)
+	clock.Advance(100 * time.Millisecond)
+
+	if equalInts(receivedHeaders(callback.messagesReceived), []int{1, 2, 1, 2}) != true {
+		t.Error("Expecting the messages written back released in order")
+	}
+}
@@ -0,0 +1,138 @@
+//
+//  Transformer_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"errors"
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the Transformer class.
+*/
+
+func scaleTransform(message interfaces.IPipeMessage, params interface{}) (interfaces.IPipeMessage, error) {
+	rect := message.Header().(*Rect)
+	return messages.NewMessage(messages.NORMAL, &Rect{Width: rect.Width * float32(params.(Factor).factor), Height: rect.Height * float32(params.(Factor).factor)}, nil, message.Priority()), nil
+}
+
+/*
+Test transforming a normal message into a new message.
+*/
+func TestTransformingNormalMessage(t *testing.T) {
+	callback := Callback{}
+	transformer := &plumbing.Transformer{Name: "scale", Transform: scaleTransform, Params: Factor{factor: 10},
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	message := messages.NewMessage(messages.NORMAL, &Rect{Width: 10, Height: 2}, nil, messages.PRIORITY_MED)
+	written := transformer.Write(message)
+
+	if written != true {
+		t.Error("Expecting wrote message to transformer")
+	}
+	if len(callback.messagesReceived) != 1 {
+		t.Fatal("Expecting received 1 message")
+	}
+	received := callback.messagesReceived[0]
+	if received == message {
+		t.Error("Expecting received a new message")
+	}
+	if received.Header().(*Rect).Width != 100 || received.Header().(*Rect).Height != 20 {
+		t.Error("Expecting received rect scaled by 10")
+	}
+	if message.Header().(*Rect).Width != 10 {
+		t.Error("Expecting original message unchanged")
+	}
+}
+
+/*
+Test expanding a message into many and a failing transform.
+*/
+func TestTransformerExpandAndError(t *testing.T) {
+	callback := Callback{}
+	transformer := &plumbing.Transformer{Name: "words",
+		Expand: func(message interfaces.IPipeMessage, params interface{}) ([]interfaces.IPipeMessage, error) {
+			words, ok := message.Body().([]string)
+			if !ok {
+				return nil, errors.New("body is not []string")
+			}
+			var results []interfaces.IPipeMessage
+			for _, word := range words {
+				results = append(results, messages.NewMessage(messages.NORMAL, nil, word, message.Priority()))
+			}
+			return results, nil
+		},
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	written := transformer.Write(messages.NewMessage(messages.NORMAL, nil, []string{"one", "two", "three"}, messages.PRIORITY_MED))
+	if written != true {
+		t.Error("Expecting wrote message to transformer")
+	}
+	if len(callback.messagesReceived) != 3 || callback.messagesReceived[2].Body() != "three" {
+		t.Error("Expecting received 3 messages in order")
+	}
+
+	failed := transformer.Write(messages.NewMessage(messages.NORMAL, nil, 42, messages.PRIORITY_MED))
+	if failed != false {
+		t.Error("Expecting transform error fails the write")
+	}
+	if len(callback.messagesReceived) != 3 {
+		t.Error("Expecting nothing written on transform error")
+	}
+}
+
+/*
+Test reconfiguring a transformer by control messages.
+*/
+func TestTransformerControlMessages(t *testing.T) {
+	callback := Callback{}
+	transformer := &plumbing.Transformer{Name: "scale", Transform: scaleTransform, Params: Factor{factor: 10},
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	// set params
+	transformer.Write(messages.NewTransformerControlMessage(messages.SET_TRANSFORM_PARAMS, "scale", nil, Factor{factor: 2}))
+	transformer.Write(messages.NewMessage(messages.NORMAL, &Rect{Width: 10, Height: 2}, nil, messages.PRIORITY_MED))
+	if callback.messagesReceived[0].Header().(*Rect).Width != 20 {
+		t.Error("Expecting rect scaled by new params")
+	}
+
+	// bypass
+	transformer.Write(messages.NewTransformerControlMessage(messages.BYPASS_TRANSFORM, "scale", nil, nil))
+	bypassed := messages.NewMessage(messages.NORMAL, &Rect{Width: 10, Height: 2}, nil, messages.PRIORITY_MED)
+	transformer.Write(bypassed)
+	if callback.messagesReceived[1] != bypassed {
+		t.Error("Expecting bypassed message written unchanged")
+	}
+
+	// back to transform with a new function
+	transformer.Write(messages.NewTransformerControlMessage(messages.TRANSFORM, "scale", nil, nil))
+	transformer.Write(messages.NewTransformerControlMessage(messages.SET_TRANSFORM, "scale",
+		func(message interfaces.IPipeMessage, params interface{}) (interfaces.IPipeMessage, error) {
+			return nil, nil
+		}, nil))
+	dropped := transformer.Write(messages.NewMessage(messages.NORMAL, &Rect{}, nil, messages.PRIORITY_MED))
+	if dropped != true || len(callback.messagesReceived) != 2 {
+		t.Error("Expecting nil result drops the message")
+	}
+
+	// control messages for other fittings pass through
+	other := messages.NewTransformerControlMessage(messages.BYPASS_TRANSFORM, "other", nil, nil)
+	transformer.Write(other)
+	flush := messages.NewQueueControlMessage(messages.FLUSH)
+	transformer.Write(flush)
+	if len(callback.messagesReceived) != 4 || callback.messagesReceived[2] != other || callback.messagesReceived[3] != flush {
+		t.Error("Expecting other control messages written through")
+	}
+	if transformer.Mode != messages.TRANSFORM {
+		t.Error("Expecting transformer still in transform mode")
+	}
+}
@@ -0,0 +1,88 @@
+//
+//  Verifier_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+)
+
+/*
+Test the Verifier class.
+*/
+
+/*
+Test that messages signed with any known key pass verification, across a key rotation.
+*/
+func TestVerifierAcceptsSignedMessages(t *testing.T) {
+	output := &FailingFitting{}
+	verifier := &plumbing.Verifier{Name: "verifier", Keys: testKeys, Pipe: plumbing.Pipe{Output: output}}
+	signer := &plumbing.Signer{Name: "signer", Keys: testKeys, KeyID: "2019-01", Pipe: plumbing.Pipe{Output: verifier}}
+
+	signer.Write(messages.NewMessage(messages.NORMAL, 1, []int{1, 2}, messages.PRIORITY_MED))
+	signer.Rotate("2019-02")
+	signer.Write(messages.NewMessage(messages.NORMAL, 2, map[string]string{"k": "v"}, messages.PRIORITY_MED))
+
+	if len(output.messagesReceived) != 2 {
+		t.Error("Expecting messages signed with the old and the new key verified")
+	}
+	if messages.Metadata(output.messagesReceived[1], messages.METADATA_SIGNATURE_KEY_ID) != "2019-02" {
+		t.Error("Expecting the second message signed with the new key")
+	}
+	if signer.Write(messages.NewQueueControlMessage(messages.FLUSH)) != true || len(output.messagesReceived) != 3 {
+		t.Error("Expecting control messages written through")
+	}
+	if status := verifier.Status(); status["verified"] != 2 || status["rejected"] != 0 {
+		t.Error("Expecting the status to count verified messages")
+	}
+}
+
+/*
+Test that tampered, unsigned and unknown-key messages are rejected, or quarantined.
+*/
+func TestVerifierRejectsMessages(t *testing.T) {
+	output, quarantine := &FailingFitting{}, &FailingFitting{}
+	verifier := &plumbing.Verifier{Name: "verifier", Keys: plumbing.StaticKeys{"2019-02": testKeys["2019-02"]}, Pipe: plumbing.Pipe{Output: output}}
+	signer := &plumbing.Signer{Name: "signer", Keys: testKeys, KeyID: "2019-02", Pipe: plumbing.Pipe{Output: &FailingFitting{}}}
+
+	tampered := messages.NewMessage(messages.NORMAL, 1, "pay 10", messages.PRIORITY_MED)
+	signer.Sign(tampered)
+	tampered.SetBody("pay 1000")
+	reprioritized := messages.NewMessage(messages.NORMAL, 1, "pay 10", messages.PRIORITY_MED)
+	signer.Sign(reprioritized)
+	reprioritized.SetPriority(messages.PRIORITY_HIGH)
+	unsigned := messages.NewMessage(messages.NORMAL, 1, "pay 10", messages.PRIORITY_MED)
+	oldKey := messages.NewMessage(messages.NORMAL, 1, "pay 10", messages.PRIORITY_MED)
+	signer.Rotate("2019-01")
+	signer.Sign(oldKey)
+	relabelled := messages.NewMessage(messages.NORMAL, 1, "pay 10", messages.PRIORITY_MED)
+	signer.Sign(relabelled)
+	messages.SetMetadata(relabelled, messages.METADATA_SIGNATURE_KEY_ID, "2019-02")
+	garbled := messages.NewMessage(messages.NORMAL, 1, "pay 10", messages.PRIORITY_MED)
+	messages.SetMetadata(garbled, messages.METADATA_SIGNATURE, "not base64!")
+
+	for _, message := range []interfaces.IPipeMessage{tampered, reprioritized, unsigned, oldKey, relabelled, garbled} {
+		if verifier.Write(message) != false {
+			t.Error("Expecting the message rejected")
+		}
+	}
+	if len(output.messagesReceived) != 0 {
+		t.Error("Expecting no rejected message written to the output")
+	}
+
+	verifier.Quarantine = quarantine
+	if verifier.Write(tampered) != true || len(quarantine.messagesReceived) != 1 || len(output.messagesReceived) != 0 {
+		t.Error("Expecting the rejected message written to the quarantine")
+	}
+	if status := verifier.Status(); status["rejected"] != 7 {
+		t.Error("Expecting the status to count rejected messages")
+	}
+}
@@ -0,0 +1,191 @@
+//
+//  Window_test.go
+//  PureMVC Go Multicore Utility - Pipes
+//
+//  Copyright(c) 2019 Saad Shams <saad.shams@puremvc.org>
+//  Your reuse is governed by the Creative Commons Attribution 3.0 License
+//
+
+package plumbing
+
+import (
+	"github.com/puremvc/puremvc-go-util-pipes/src/interfaces"
+	"github.com/puremvc/puremvc-go-util-pipes/src/messages"
+	"github.com/puremvc/puremvc-go-util-pipes/src/plumbing"
+	"testing"
+	"time"
+)
+
+/*
+Test the Window class.
+*/
+
+// windowSizes Get the number of messages in each window written.
+func windowSizes(received []interfaces.IPipeMessage) []int {
+	var sizes []int
+	for _, message := range received {
+		sizes = append(sizes, len(message.Body().([]interfaces.IPipeMessage)))
+	}
+	return sizes
+}
+
+/*
+Test that tumbling windows close as the clock passes their end.
+*/
+func TestWindowTumbling(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	window := &plumbing.Window{Kind: plumbing.WINDOW_TUMBLING, Size: 10 * time.Second, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	message1 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	message2 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+	message3 := messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)
+
+	window.Write(message1)
+	clock.Advance(4 * time.Second)
+	window.Write(message2)
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting nothing written before the window ends")
+	}
+
+	clock.Advance(6 * time.Second)
+	if len(callback.messagesReceived) != 1 {
+		t.Fatal("Expecting 1 window written at its end")
+	}
+	batch := callback.messagesReceived[0].Body().([]interfaces.IPipeMessage)
+	if len(batch) != 2 || batch[0] != message1 || batch[1] != message2 {
+		t.Error("Expecting window holds the first 2 messages in order")
+	}
+
+	window.Write(message3)
+	clock.Advance(10 * time.Second)
+	if !equalInts(windowSizes(callback.messagesReceived), []int{2, 1}) {
+		t.Error("Expecting the next window holds the third message")
+	}
+}
+
+/*
+Test that a message falls into every sliding window that covers it.
+*/
+func TestWindowSliding(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	window := &plumbing.Window{Kind: plumbing.WINDOW_SLIDING, Size: 10 * time.Second, Slide: 5 * time.Second, Clock: clock,
+		Reduce: func(start time.Time, end time.Time, batch []interfaces.IPipeMessage) interfaces.IPipeMessage {
+			return messages.NewMessage(messages.NORMAL, start, batch, messages.PRIORITY_MED)
+		},
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	clock.Advance(2 * time.Second)
+	window.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) // in [-5s,5s) and [0s,10s)
+	clock.Advance(5 * time.Second)
+	window.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) // in [0s,10s) and [5s,15s)
+	clock.Advance(10 * time.Second)
+
+	if !equalInts(windowSizes(callback.messagesReceived), []int{1, 2, 1}) {
+		t.Fatal("Expecting 3 overlapping windows written")
+	}
+	for index, offset := range []int{-5, 0, 5} {
+		if !callback.messagesReceived[index].Header().(time.Time).Equal(epoch.Add(time.Duration(offset) * time.Second)) {
+			t.Error("Expecting windows written in order of their end")
+		}
+	}
+}
+
+/*
+Test that a session window closes after a gap of inactivity.
+*/
+func TestWindowSession(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	window := &plumbing.Window{Kind: plumbing.WINDOW_SESSION, Gap: 3 * time.Second, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	for i := 0; i < 3; i++ {
+		window.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+		clock.Advance(2 * time.Second)
+	}
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting the session kept open while messages arrive within the gap")
+	}
+
+	clock.Advance(time.Second)
+	if !equalInts(windowSizes(callback.messagesReceived), []int{3}) {
+		t.Error("Expecting the session of 3 messages written after the gap")
+	}
+}
+
+/*
+Test that event time windows close as timestamps pass their end and late messages are dropped.
+*/
+func TestWindowEventTime(t *testing.T) {
+	callback := Callback{}
+	window := &plumbing.Window{Kind: plumbing.WINDOW_TUMBLING, Size: 10 * time.Second, EventTime: true,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	window.Write(newEventMessage(1))
+	window.Write(newEventMessage(8))
+	window.Write(newEventMessage(3))
+	if len(callback.messagesReceived) != 0 {
+		t.Error("Expecting nothing written before a timestamp passes the window end")
+	}
+
+	window.Write(newEventMessage(12))
+	if !equalInts(windowSizes(callback.messagesReceived), []int{3}) {
+		t.Fatal("Expecting the first window written with 3 messages")
+	}
+	batch := callback.messagesReceived[0].Body().([]interfaces.IPipeMessage)
+	if !equalInts(receivedSeconds(batch), []int{1, 8, 3}) {
+		t.Error("Expecting window holds its messages in arrival order")
+	}
+
+	if window.Write(newEventMessage(5)) != false {
+		t.Error("Expecting late message for a closed window dropped")
+	}
+	if window.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED)) != false {
+		t.Error("Expecting message without a timestamp dropped")
+	}
+
+	window.Write(messages.NewQueueControlMessage(messages.FLUSH))
+	if len(callback.messagesReceived) != 3 || callback.messagesReceived[2].Type() != messages.FLUSH {
+		t.Error("Expecting flush writes the open window, then the flush message")
+	}
+}
+
+/*
+Test that a window control message closes the open windows and changes the parameters.
+*/
+func TestWindowControlMessage(t *testing.T) {
+	clock := NewFakeClock()
+	callback := Callback{}
+	window := &plumbing.Window{Name: "window", Kind: plumbing.WINDOW_TUMBLING, Size: 10 * time.Second, Clock: clock,
+		Pipe: plumbing.Pipe{Output: &plumbing.PipeListener{Context: callback, Listener: callback.CallbackMethod}}}
+
+	window.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+
+	other := messages.NewWindowControlMessage(messages.SET_WINDOW, "other", plumbing.WINDOW_SESSION, 0, 0, time.Second)
+	window.Write(other)
+	if len(callback.messagesReceived) != 1 || callback.messagesReceived[0] != other {
+		t.Fatal("Expecting control message for another window written through")
+	}
+
+	control := messages.NewWindowControlMessage(messages.SET_WINDOW, "window", plumbing.WINDOW_SESSION, 0, 0, time.Second)
+	if window.Write(control) != true {
+		t.Error("Expecting control message accepted")
+	}
+	if len(callback.messagesReceived) != 2 || window.Kind != plumbing.WINDOW_SESSION || window.Gap != time.Second {
+		t.Fatal("Expecting open window closed and session parameters applied")
+	}
+
+	clock.Advance(10 * time.Second)
+	if len(callback.messagesReceived) != 2 {
+		t.Error("Expecting the timer of the closed window stopped")
+	}
+
+	window.Write(messages.NewMessage(messages.NORMAL, nil, nil, messages.PRIORITY_MED))
+	clock.Advance(time.Second)
+	if len(callback.messagesReceived) != 3 {
+		t.Error("Expecting the session window written after the new gap")
+	}
+}